@@ -0,0 +1,126 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amount is an amount of money in hundredths of the currency unit (e.g.
+// Rappen or cents), so that amounts never go through floating point.
+type Amount int64
+
+// Amounts permitted by the Implementation Guidelines. An amount of 0.00 is
+// only permitted for notifications.
+const (
+	MinAmount Amount = 1           // 0.01
+	MaxAmount Amount = 99999999999 // 999,999,999.99
+)
+
+// AmountFromCents returns the Amount of the specified number of hundredths of
+// the currency unit, e.g. AmountFromCents(5030) is 50.30.
+func AmountFromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// NewAmount returns the Amount of units and cents, e.g. NewAmount(50, 30) is
+// 50.30. cents must be between 0 and 99.
+func NewAmount(units, cents int64) (Amount, error) {
+	if units < 0 {
+		return 0, fmt.Errorf("amount units must not be negative, got %d", units)
+	}
+	if cents < 0 || cents > 99 {
+		return 0, fmt.Errorf("amount cents must be between 0 and 99, got %d", cents)
+	}
+	if units > int64(MaxAmount/100) {
+		return 0, fmt.Errorf("amount %d.%02d exceeds the maximum amount %s", units, cents, MaxAmount)
+	}
+	return Amount(units*100 + cents), nil
+}
+
+// parseDecimal splits s into the integer and fractional digits, e.g. "50.3"
+// into "50" and "3".
+func parseDecimal(s string) (units, fraction string, err error) {
+	units, fraction, _ = strings.Cut(s, ".")
+	if units == "" && fraction == "" {
+		return "", "", fmt.Errorf("amount %q contains no digits", s)
+	}
+	if !isDigits(units) || !isDigits(fraction) {
+		return "", "", fmt.Errorf("amount %q must only contain digits and a decimal point", s)
+	}
+	// Limit the integer part to prevent overflows:
+	if units = strings.TrimLeft(units, "0"); len(units) > 9 {
+		return "", "", fmt.Errorf("amount %q exceeds the maximum amount %s", s, MaxAmount)
+	}
+	return units, fraction, nil
+}
+
+// ParseAmount parses a decimal amount with up to two decimal places, using a
+// decimal point as separator, e.g. 50, 50.3 or 50.30. Amounts with more
+// decimal places are rejected instead of rounded.
+func ParseAmount(s string) (Amount, error) {
+	units, fraction, err := parseDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("amount %q has more than two decimal places", s)
+	}
+	return amountFromDigits(units, fraction), nil
+}
+
+// amountFromDigits converts the integer and (max. 2) fractional digits
+// returned by parseDecimal into an Amount.
+func amountFromDigits(units, fraction string) Amount {
+	var a int64
+	if units != "" {
+		// Cannot fail: parseDecimal verified digits and length.
+		a, _ = strconv.ParseInt(units, 10, 64)
+	}
+	fraction = (fraction + "00")[:2]
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+	return Amount(a*100 + cents)
+}
+
+// String formats a as required by the Implementation Guidelines: without
+// leading zeros, with a decimal point and two decimal places, e.g. 50.30.
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/100, a%100)
+}
+
+// Validate returns an error if a is outside of the range permitted by the
+// Implementation Guidelines (0.01 to 999,999,999.99). 0.00 is permitted for
+// notifications.
+func (a Amount) Validate() error {
+	if a == 0 {
+		return nil
+	}
+	if a < MinAmount || a > MaxAmount {
+		return fmt.Errorf("amount %s must be between %s and %s", a, MinAmount, MaxAmount)
+	}
+	return nil
+}
+
+// Amount parses the amount, see ParseAmount.
+func (a QRCHCcyAmt) Amount() (Amount, error) {
+	return ParseAmount(a.Amt)
+}
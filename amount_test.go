@@ -0,0 +1,88 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestParseAmount(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		want    qrbill.Amount
+		wantErr bool
+	}{
+		{input: "50", want: 5000},
+		{input: "50.3", want: 5030},
+		{input: "50.30", want: 5030},
+		{input: ".3", want: 30},
+		{input: "0.10", want: 10},
+		{input: "007.50", want: 750},
+		{input: "999999999.99", want: qrbill.MaxAmount},
+		{input: "1234567.89", want: 123456789}, // not exactly representable as float64
+
+		{input: "", wantErr: true},
+		{input: ".", wantErr: true},
+		{input: "50.339", wantErr: true},
+		{input: "50.-", wantErr: true},
+		{input: "50,30", wantErr: true},
+		{input: "-5.00", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "1000000000.00", wantErr: true},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := qrbill.ParseAmount(tt.input)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("ParseAmount(%q) = %v, want error: %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmount(t *testing.T) {
+	a, err := qrbill.NewAmount(50, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a, qrbill.AmountFromCents(5030); got != want {
+		t.Errorf("NewAmount(50, 30) = %d, want %d", got, want)
+	}
+	if got, want := a.String(), "50.30"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := qrbill.AmountFromCents(5).String(), "0.05"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct {
+		units, cents int64
+	}{
+		{units: 50, cents: 100},
+		{units: 50, cents: -1},
+		{units: -1, cents: 0},
+		{units: 1000000000, cents: 0},
+	} {
+		if _, err := qrbill.NewAmount(tt.units, tt.cents); err == nil {
+			t.Errorf("NewAmount(%d, %d) unexpectedly succeeded", tt.units, tt.cents)
+		}
+	}
+
+	for _, a := range []qrbill.Amount{0, qrbill.MinAmount, qrbill.MaxAmount} {
+		if err := a.Validate(); err != nil {
+			t.Errorf("Validate(%s) = %v", a, err)
+		}
+	}
+	for _, a := range []qrbill.Amount{-1, qrbill.MaxAmount + 1} {
+		if err := a.Validate(); err == nil {
+			t.Errorf("Validate(%s) unexpectedly succeeded", a)
+		}
+	}
+
+	amt := qrbill.QRCHCcyAmt{Amt: "50.30", Ccy: "CHF"}
+	if got, err := amt.Amount(); err != nil || got != a {
+		t.Errorf("QRCHCcyAmt.Amount() = %v, %v, want %v", got, err, a)
+	}
+}
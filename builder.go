@@ -0,0 +1,158 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+// Builder constructs a QRCH step by step, e.g.:
+//
+//	bill, err := qrbill.NewBuilder().
+//		Creditor("CH44 3199 9123 0008 8901 2", creditor).
+//		Amount(qrbill.AmountFromCents(5000), qrbill.CurrencyCHF).
+//		Debtor(debtor).
+//		Reference("210000000003139471430009017").
+//		Message("Invoice 4711").
+//		Build()
+//
+// All methods return the Builder for chaining. Validation happens in Build.
+type Builder struct {
+	qrch QRCH
+
+	// refType is the explicitly selected reference type, or empty to select
+	// the reference type based on the IBAN.
+	refType string
+
+	// err is the first error of a step, returned by Build.
+	err error
+}
+
+// NewBuilder returns a Builder for a bill in CHF without amount (to be filled
+// in by the debtor), without debtor and without reference.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Creditor sets the IBAN (or QR-IBAN) and address of the creditor.
+func (b *Builder) Creditor(iban string, addr Address) *Builder {
+	b.qrch.CdtrInf = QRCHCdtrInf{
+		IBAN: iban,
+		Cdtr: addr,
+	}
+	return b
+}
+
+// Amount sets the amount and currency. Without an amount, the debtor fills
+// in the amount when paying.
+func (b *Builder) Amount(amount Amount, ccy Currency) *Builder {
+	b.qrch.CcyAmt = QRCHCcyAmt{
+		Amt: amount.String(),
+		Ccy: ccy,
+	}
+	return b
+}
+
+// Currency sets the currency of a bill without amount.
+func (b *Builder) Currency(ccy Currency) *Builder {
+	b.qrch.CcyAmt.Ccy = ccy
+	return b
+}
+
+// Notification turns the bill into a notification in the specified language,
+// see QRCH.SetNotification. The amount and message are overwritten.
+func (b *Builder) Notification(lang Language) *Builder {
+	if err := b.qrch.SetNotification(lang); err != nil && b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Debtor sets the address of the (ultimate) debtor.
+func (b *Builder) Debtor(addr Address) *Builder {
+	b.qrch.UltmtDbtr = addr
+	return b
+}
+
+// Reference sets the reference and selects the reference type based on the
+// IBAN: a QR reference (QRR) for QR-IBANs, a Creditor Reference (SCOR)
+// otherwise.
+func (b *Builder) Reference(ref string) *Builder {
+	b.qrch.RmtInf.Ref = ref
+	b.refType = ""
+	return b
+}
+
+// QRReference sets a QR reference (QRR), which requires a QR-IBAN. See
+// QRReference for creating a QR reference.
+func (b *Builder) QRReference(ref string) *Builder {
+	b.qrch.RmtInf.Ref = ref
+	b.refType = ReferenceTypeQRR
+	return b
+}
+
+// CreditorReference sets a Creditor Reference (SCOR), which requires an IBAN
+// which is not a QR-IBAN. See CreditorReference for creating a Creditor
+// Reference.
+func (b *Builder) CreditorReference(ref string) *Builder {
+	b.qrch.RmtInf.Ref = ref
+	b.refType = ReferenceTypeSCOR
+	return b
+}
+
+// Message sets the unstructured message.
+func (b *Builder) Message(msg string) *Builder {
+	b.qrch.RmtInf.AddInf.Ustrd = msg
+	return b
+}
+
+// BillInformation sets the bill information, e.g. in Swico S1 syntax (see
+// SwicoS1.String).
+func (b *Builder) BillInformation(info string) *Builder {
+	b.qrch.RmtInf.AddInf.StrdBkgInf = info
+	return b
+}
+
+// AlternativeProcedure adds the parameters of an alternative procedure (max.
+// 2).
+func (b *Builder) AlternativeProcedure(params string) *Builder {
+	b.qrch.AltPmtInf.AltPmt = append(b.qrch.AltPmtInf.AltPmt, params)
+	return b
+}
+
+// QRCH returns a copy of the QRCH built so far, with the reference type
+// selected.
+func (b *Builder) QRCH() *QRCH {
+	q := b.qrch
+	q.AltPmtInf.AltPmt = append([]string(nil), q.AltPmtInf.AltPmt...)
+	switch {
+	case b.refType != "":
+		q.RmtInf.Tp = b.refType
+	case IsQRIBAN(q.CdtrInf.IBAN):
+		q.RmtInf.Tp = ReferenceTypeQRR
+	case q.RmtInf.Ref != "":
+		q.RmtInf.Tp = ReferenceTypeSCOR
+	default:
+		q.RmtInf.Tp = ReferenceTypeNON
+	}
+	if q.CcyAmt.Ccy == "" {
+		q.CcyAmt.Ccy = CurrencyCHF
+	}
+	return &q
+}
+
+// Build validates and encodes the QRCH, see QRCH.Encode.
+func (b *Builder) Build(opts ...Option) (*Bill, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.QRCH().Encode(opts...)
+}
@@ -0,0 +1,113 @@
+package qrbill_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestBuilder(t *testing.T) {
+	qrch := testQRCH()
+	creditor := qrch.CdtrInf.Cdtr
+	debtor := qrch.UltmtDbtr
+
+	bill, err := qrbill.NewBuilder().
+		Creditor("CH0209000000870913543", creditor).
+		Amount(qrbill.AmountFromCents(5000), qrbill.CurrencyCHF).
+		Debtor(debtor).
+		Message("Spende 420").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := qrch.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bill.EncodeToString(), want.EncodeToString(); got != want {
+		t.Errorf("Build() = %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct {
+		desc    string
+		builder *qrbill.Builder
+		wantTp  string
+		wantErr bool
+	}{
+		{
+			desc:    "QR-IBAN",
+			builder: qrbill.NewBuilder().Creditor("CH44 3199 9123 0008 8901 2", creditor).Reference("210000000003139471430009017"),
+			wantTp:  qrbill.ReferenceTypeQRR,
+		},
+
+		{
+			desc:    "IBAN with reference",
+			builder: qrbill.NewBuilder().Creditor("CH0209000000870913543", creditor).Reference("RF18539007547034"),
+			wantTp:  qrbill.ReferenceTypeSCOR,
+		},
+
+		{
+			desc:    "IBAN without reference",
+			builder: qrbill.NewBuilder().Creditor("CH0209000000870913543", creditor),
+			wantTp:  qrbill.ReferenceTypeNON,
+		},
+
+		{
+			desc:    "QR-IBAN without reference",
+			builder: qrbill.NewBuilder().Creditor("CH4431999123000889012", creditor),
+			wantTp:  qrbill.ReferenceTypeQRR,
+			wantErr: true,
+		},
+
+		{
+			desc:    "explicit SCOR with QR-IBAN",
+			builder: qrbill.NewBuilder().Creditor("CH4431999123000889012", creditor).CreditorReference("RF18539007547034"),
+			wantTp:  qrbill.ReferenceTypeSCOR,
+			wantErr: true,
+		},
+
+		{
+			desc:    "explicit QRR",
+			builder: qrbill.NewBuilder().Creditor("CH4431999123000889012", creditor).QRReference("210000000003139471430009017"),
+			wantTp:  qrbill.ReferenceTypeQRR,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := tt.builder.QRCH().RmtInf.Tp; got != tt.wantTp {
+				t.Errorf("reference type = %q, want %q", got, tt.wantTp)
+			}
+			_, err := tt.builder.Build()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("Build() = %v, want error: %v", err, tt.wantErr)
+			}
+			var verr *qrbill.ValidationError
+			if err != nil && !errors.As(err, &verr) {
+				t.Errorf("Build() = %v, want *ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestBuilderValidation(t *testing.T) {
+	_, err := qrbill.NewBuilder().
+		Creditor("CH0209000000870913543", qrbill.Address{}).
+		Currency(qrbill.CurrencyEUR).
+		Build()
+	var verr *qrbill.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Build() = %v, want *ValidationError", err)
+	}
+	if got, want := verr.Violations[0].Field, "CdtrInf.Cdtr"; got != want {
+		t.Errorf("violation field = %q, want %q", got, want)
+	}
+
+	// Build passes options on to Encode:
+	_, err = qrbill.NewBuilder().
+		Creditor("CH0209000000870913543", testQRCH().CdtrInf.Cdtr).
+		Debtor(testQRCH().UltmtDbtr).
+		Build(qrbill.WithSpecVersion(qrbill.SpecVersion23))
+	if !errors.As(err, &verr) {
+		t.Fatalf("Build(v2.3) = %v, want *ValidationError", err)
+	}
+}
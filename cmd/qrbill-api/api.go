@@ -16,11 +16,14 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"image/png"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -56,7 +59,7 @@ func qrchFromRequest(r *http.Request) *qrbill.QRCH {
 		},
 		CcyAmt: qrbill.QRCHCcyAmt{
 			Amt: ifEmpty(r.Form, "amount", ""),
-			Ccy: "CHF",
+			Ccy: qrbill.Currency(ifEmpty(r.Form, "currency", string(qrbill.CurrencyCHF))),
 		},
 		UltmtDbtr: qrbill.Address{
 			AdrTp:            qrbill.AddressType(ifEmpty(r.Form, "udaddrtype", string(qrbill.AddressTypeCombined))),
@@ -103,11 +106,14 @@ func logic() error {
 		if format != "png" &&
 			format != "svg" &&
 			format != "pdf" &&
+			format != "slippdf" &&
+			format != "slipsvg" &&
+			format != "a4pdf" &&
 			format != "txt" &&
 			format != "html" &&
 			format != "wv" &&
 			format != "eps" {
-			msg := fmt.Sprintf("format (%q) must be one of png, svg, pdf, eps, txt or html", format)
+			msg := fmt.Sprintf("format (%q) must be one of png, svg, pdf, slippdf, slipsvg, a4pdf, eps, txt or html", format)
 			log.Printf("%s %s", prefix, msg)
 			http.Error(w, msg, http.StatusBadRequest)
 			return
@@ -120,14 +126,51 @@ func logic() error {
 		}
 
 		qrch := qrchFromRequest(r)
+		// Alternatively, the QRCH can be POSTed in its JSON representation
+		// (see qrbill.schema.json), in which case the form values describing
+		// the bill are ignored:
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+			qrch = &qrbill.QRCH{}
+			if err := json.NewDecoder(r.Body).Decode(qrch); err != nil {
+				log.Printf("%s %s", prefix, err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		// The notification parameter applies to both:
+		if lang := r.FormValue("notification"); lang != "" {
+			if err := qrch.SetNotification(qrbill.Language(lang)); err != nil {
+				log.Printf("%s %s", prefix, err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 
 		bill, err := qrch.Encode()
 		if err != nil {
 			log.Printf("%s %s", prefix, err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			status := http.StatusInternalServerError
+			var verr *qrbill.ValidationError
+			if errors.As(err, &verr) ||
+				errors.Is(err, qrbill.ErrPayloadTooLong) ||
+				errors.Is(err, qrbill.ErrQRVersionTooHigh) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
 			return
 		}
 
+		// Only the payment slip formats contain labels:
+		var slipOpts []qrbill.SlipOption
+		if format == "slippdf" || format == "slipsvg" || format == "a4pdf" {
+			lang := qrbill.Language(ifEmpty(r.Form, "lang", string(qrbill.LanguageEnglish)))
+			if err := lang.Validate(); err != nil {
+				log.Printf("%s %s", prefix, err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			slipOpts = append(slipOpts, qrbill.WithLanguage(lang))
+		}
 		var b []byte
 		switch format {
 		case "png":
@@ -169,6 +212,39 @@ func logic() error {
 
 			w.Header().Add("Content-Type", "application/pdf")
 
+		case "slippdf":
+			var err error
+			b, err = bill.EncodeToPaymentSlipPDF(slipOpts...)
+			if err != nil {
+				log.Printf("%s %s", prefix, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Add("Content-Type", "application/pdf")
+
+		case "slipsvg":
+			var err error
+			b, err = bill.EncodeToPaymentSlipSVG(slipOpts...)
+			if err != nil {
+				log.Printf("%s %s", prefix, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Add("Content-Type", "image/svg+xml")
+
+		case "a4pdf":
+			var err error
+			b, err = bill.EncodeToA4PDF(nil, slipOpts...)
+			if err != nil {
+				log.Printf("%s %s", prefix, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Add("Content-Type", "application/pdf")
+
 		case "eps":
 			var err error
 			b, err = bill.EncodeToEPS()
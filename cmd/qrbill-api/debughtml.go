@@ -130,6 +130,21 @@ th { text-align: left; }
   <td>{{ .Amount }}</td>
 </tr>
 
+<tr>
+  <td>&currency=</td>
+  <td>{{ .Currency }}</td>
+</tr>
+
+<tr>
+  <td>&notification=</td>
+  <td>{{ .Notification }}</td>
+</tr>
+
+<tr>
+  <td>&lang=</td>
+  <td>{{ .Lang }}</td>
+</tr>
+
 
 <tr>
   <td>&message=</td>
@@ -169,7 +184,10 @@ func debugHTML(w http.ResponseWriter, r *http.Request, prefix string, qrch *qrbi
 
 		Message string
 
-		Amount string
+		Amount       string
+		Currency     string
+		Notification string
+		Lang         string
 	}{
 		Criban: r.FormValue("criban"),
 
@@ -190,7 +208,10 @@ func debugHTML(w http.ResponseWriter, r *http.Request, prefix string, qrch *qrbi
 
 		Message: r.FormValue("message"),
 
-		Amount: r.FormValue("amount"),
+		Amount:       r.FormValue("amount"),
+		Currency:     r.FormValue("currency"),
+		Notification: r.FormValue("notification"),
+		Lang:         r.FormValue("lang"),
 	})
 	if err != nil {
 		log.Printf("%s %s", prefix, err)
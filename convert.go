@@ -0,0 +1,197 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Confidence indicates how reliably Structured split a combined address.
+type Confidence int
+
+const (
+	// ConfidenceHigh means the address matched a common pattern and was
+	// split unambiguously.
+	ConfidenceHigh Confidence = iota
+
+	// ConfidenceLow means the address was split, but it did not match a
+	// common pattern exactly and should be reviewed.
+	ConfidenceLow
+
+	// ConfidenceNone means (part of) the address could not be split, or the
+	// country could not be determined. The unsplit text was kept in the
+	// street name or town, respectively.
+	ConfidenceNone
+)
+
+// String implements fmt.Stringer.
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceHigh:
+		return "high"
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceNone:
+		return "none"
+	}
+	return "unknown"
+}
+
+// lower returns the lower of both confidences.
+func (c Confidence) lower(other Confidence) Confidence {
+	if other > c {
+		return other
+	}
+	return c
+}
+
+var (
+	// postOfficeBoxRe matches post office boxes in the Swiss languages,
+	// optionally followed by a number, e.g. “Postfach 1234”.
+	postOfficeBoxRe = regexp.MustCompile(`(?i)^(postfach|case postale|casella postale|cp|p\.?o\.? box)(\s+\d+)?$`)
+
+	// streetNumberRe matches a street followed by a building number, e.g.
+	// “Bahnhofstr. 12a”, “Via Cantonale 3/1” or “Rue du Lac 12 bis”.
+	streetNumberRe = regexp.MustCompile(`^(.*[^\d\s,]),?\s+(\d+\s?(?:[a-zA-Z]|bis|ter)?(?:[/-]\d+[a-zA-Z]?)?)$`)
+
+	// numberStreetRe matches a building number followed by a street, as is
+	// common in the French-speaking part, e.g. “12, rue du Lac”.
+	numberStreetRe = regexp.MustCompile(`^(\d+\s?[a-zA-Z]?),?\s+(\D.*)$`)
+
+	// postalCodeTownRe matches a Swiss or Liechtenstein postal code with an
+	// optional country prefix, followed by the town, e.g. “CH-8004 Zürich”.
+	postalCodeTownRe = regexp.MustCompile(`^(?:(CH|LI|FL)[- ]?)?(\d{4})\s+(.+)$`)
+
+	// foreignPostalCodeTownRe matches postal codes of other countries, which
+	// are not necessarily written before the town.
+	foreignPostalCodeTownRe = regexp.MustCompile(`^(?:([A-Z]{1,2})-)?([0-9][0-9A-Z-]{2,9})\s+(.+)$`)
+)
+
+// vehiclePrefixes maps international vehicle registration codes, which are
+// commonly used as postal code prefixes (e.g. “D-79539 Lörrach” or “FL-9490
+// Vaduz”), to ISO 3166-1 alpha-2 country codes.
+var vehiclePrefixes = map[string]string{
+	"A":  "AT",
+	"B":  "BE",
+	"D":  "DE",
+	"E":  "ES",
+	"F":  "FR",
+	"FL": "LI",
+	"I":  "IT",
+	"L":  "LU",
+	"P":  "PT",
+}
+
+// prefixCountry returns the country code for a postal code prefix, or the
+// empty string if the prefix is not known.
+func prefixCountry(prefix string) string {
+	if ctry, ok := vehiclePrefixes[prefix]; ok {
+		return ctry
+	}
+	if _, ok := CountryName(prefix); ok {
+		return prefix
+	}
+	return ""
+}
+
+// splitStreet splits address line 1 into street name and building number.
+func splitStreet(line string) (street, number string, c Confidence) {
+	line = strings.Join(strings.Fields(line), " ")
+	if line == "" || postOfficeBoxRe.MatchString(line) {
+		return line, "", ConfidenceHigh
+	}
+	if m := streetNumberRe.FindStringSubmatch(line); m != nil {
+		street, number = m[1], strings.ReplaceAll(m[2], " ", "")
+		if strings.ContainsAny(street, "0123456789,") {
+			// e.g. “Bahnhofstr. 12, Postfach 3”
+			return line, "", ConfidenceNone
+		}
+		return street, number, ConfidenceHigh
+	}
+	if m := numberStreetRe.FindStringSubmatch(line); m != nil {
+		street, number = m[2], strings.ReplaceAll(m[1], " ", "")
+		if strings.ContainsAny(street, "0123456789") {
+			return line, "", ConfidenceNone
+		}
+		return street, number, ConfidenceLow
+	}
+	if strings.ContainsAny(line, "0123456789") {
+		return line, "", ConfidenceNone
+	}
+	// A street without building number, e.g. “Dorfplatz”:
+	return line, "", ConfidenceHigh
+}
+
+// splitTown splits address line 2 into postal code and town. ctry is the
+// (upper-case) country of the address and is filled in from the country
+// prefix if empty. Without country and known prefix, or if the prefix
+// contradicts the country, the confidence is ConfidenceNone, as the country
+// cannot be determined.
+func splitTown(line, ctry string) (postalCode, town, country string, c Confidence) {
+	line = strings.Join(strings.Fields(line), " ")
+	if m := postalCodeTownRe.FindStringSubmatch(line); m != nil && (ctry == "" || ctry == "CH" || ctry == "LI") {
+		switch prefix := prefixCountry(m[1]); {
+		case prefix != "" && ctry != "" && prefix != ctry:
+			return m[2], m[3], ctry, ConfidenceNone
+		case prefix != "":
+			ctry = prefix
+		case ctry == "":
+			ctry = "CH"
+		}
+		return m[2], m[3], ctry, ConfidenceHigh
+	}
+	if m := foreignPostalCodeTownRe.FindStringSubmatch(line); m != nil {
+		switch prefix := prefixCountry(m[1]); {
+		case prefix != "" && ctry != "" && prefix != ctry:
+			return m[2], m[3], ctry, ConfidenceNone
+		case ctry == "":
+			ctry = prefix
+		}
+		if ctry == "" {
+			return m[2], m[3], "", ConfidenceNone
+		}
+		return m[2], m[3], ctry, ConfidenceLow
+	}
+	return "", line, ctry, ConfidenceNone
+}
+
+// Structured converts a combined address (type K), which contains street and
+// building number in StrtNmOrAdrLine1 and postal code and town in
+// BldgNbOrAdrLine2, into a structured address (type S), as required by
+// version 2.3 of the Implementation Guidelines.
+//
+// Common Swiss and Liechtenstein patterns are recognized, e.g. “Bahnhofstr.
+// 12a”, “Postfach 1234” or “CH-8004 Zürich”. The returned Confidence
+// indicates whether the address should be reviewed. Addresses which are not
+// combined are returned unmodified with ConfidenceHigh.
+func (a Address) Structured() (Address, Confidence) {
+	if a.AdrTp != AddressTypeCombined {
+		return a, ConfidenceHigh
+	}
+	street, number, streetConfidence := splitStreet(a.StrtNmOrAdrLine1)
+	// Like Validate, accept lower-case country codes:
+	ctry := strings.ToUpper(strings.TrimSpace(a.Ctry))
+	postalCode, town, country, townConfidence := splitTown(a.BldgNbOrAdrLine2, ctry)
+	return Address{
+		AdrTp:            AddressTypeStructured,
+		Name:             a.Name,
+		StrtNmOrAdrLine1: street,
+		BldgNbOrAdrLine2: number,
+		PstCd:            postalCode,
+		TwnNm:            town,
+		Ctry:             country,
+	}, streetConfidence.lower(townConfidence)
+}
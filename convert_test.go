@@ -0,0 +1,205 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestStructured(t *testing.T) {
+	for _, tt := range []struct {
+		line1, line2, ctry string
+		want               qrbill.Address
+		wantConfidence     qrbill.Confidence
+	}{
+		{
+			line1: "Stauffacherstr 42",
+			line2: "8004 Zürich",
+			ctry:  "CH",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Stauffacherstr",
+				BldgNbOrAdrLine2: "42",
+				PstCd:            "8004",
+				TwnNm:            "Zürich",
+				Ctry:             "CH",
+			},
+		},
+
+		{
+			line1: "Bahnhofstr. 12a",
+			line2: "CH-8004  Zürich",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Bahnhofstr.",
+				BldgNbOrAdrLine2: "12a",
+				PstCd:            "8004",
+				TwnNm:            "Zürich",
+				Ctry:             "CH",
+			},
+		},
+
+		{
+			line1: "Postfach 1234",
+			line2: "FL-9490 Vaduz",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Postfach 1234",
+				PstCd:            "9490",
+				TwnNm:            "Vaduz",
+				Ctry:             "LI",
+			},
+		},
+
+		{
+			line1: "Via Cantonale 3/1",
+			line2: "6900 Lugano",
+			ctry:  "CH",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Via Cantonale",
+				BldgNbOrAdrLine2: "3/1",
+				PstCd:            "6900",
+				TwnNm:            "Lugano",
+				Ctry:             "CH",
+			},
+		},
+
+		{
+			line1: "Dorfplatz",
+			line2: "3920 Zermatt",
+			ctry:  "CH",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Dorfplatz",
+				PstCd:            "3920",
+				TwnNm:            "Zermatt",
+				Ctry:             "CH",
+			},
+		},
+
+		{
+			line1: "12, rue du Lac",
+			line2: "1200 Genève",
+			ctry:  "CH",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "rue du Lac",
+				BldgNbOrAdrLine2: "12",
+				PstCd:            "1200",
+				TwnNm:            "Genève",
+				Ctry:             "CH",
+			},
+			wantConfidence: qrbill.ConfidenceLow,
+		},
+
+		{
+			line1: "Marktstätte 1",
+			line2: "D-78462 Konstanz",
+			ctry:  "DE",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Marktstätte",
+				BldgNbOrAdrLine2: "1",
+				PstCd:            "78462",
+				TwnNm:            "Konstanz",
+				Ctry:             "DE",
+			},
+			wantConfidence: qrbill.ConfidenceLow,
+		},
+
+		{
+			line1: "Basler Str. 1",
+			line2: "D-79539 Lörrach",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Basler Str.",
+				BldgNbOrAdrLine2: "1",
+				PstCd:            "79539",
+				TwnNm:            "Lörrach",
+				Ctry:             "DE",
+			},
+			wantConfidence: qrbill.ConfidenceLow,
+		},
+
+		{
+			// Lower-case country codes are accepted, like in Validate:
+			line1: "Seestrasse 3",
+			line2: "8800 Thalwil",
+			ctry:  "ch ",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Seestrasse",
+				BldgNbOrAdrLine2: "3",
+				PstCd:            "8800",
+				TwnNm:            "Thalwil",
+				Ctry:             "CH",
+			},
+		},
+
+		{
+			// The prefix contradicts the country:
+			line1: "Tumringer Str. 10",
+			line2: "D-79539 Lörrach",
+			ctry:  "CH",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Tumringer Str.",
+				BldgNbOrAdrLine2: "10",
+				PstCd:            "79539",
+				TwnNm:            "Lörrach",
+				Ctry:             "CH",
+			},
+			wantConfidence: qrbill.ConfidenceNone,
+		},
+
+		{
+			line1: "Rue de la Gare 5",
+			line2: "74100 Annemasse",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Rue de la Gare",
+				BldgNbOrAdrLine2: "5",
+				PstCd:            "74100",
+				TwnNm:            "Annemasse",
+			},
+			wantConfidence: qrbill.ConfidenceNone,
+		},
+
+		{
+			line1: "Bahnhofstr. 12, Postfach 3",
+			line2: "Zürich",
+			ctry:  "CH",
+			want: qrbill.Address{
+				StrtNmOrAdrLine1: "Bahnhofstr. 12, Postfach 3",
+				TwnNm:            "Zürich",
+				Ctry:             "CH",
+			},
+			wantConfidence: qrbill.ConfidenceNone,
+		},
+	} {
+		t.Run(tt.line1, func(t *testing.T) {
+			addr := qrbill.Address{
+				AdrTp:            qrbill.AddressTypeCombined,
+				Name:             "Michael Stapelberg",
+				StrtNmOrAdrLine1: tt.line1,
+				BldgNbOrAdrLine2: tt.line2,
+				Ctry:             tt.ctry,
+			}
+			want := tt.want
+			want.AdrTp = qrbill.AddressTypeStructured
+			want.Name = addr.Name
+			got, confidence := addr.Structured()
+			if got != want {
+				t.Errorf("Structured() = %+v, want %+v", got, want)
+			}
+			if confidence != tt.wantConfidence {
+				t.Errorf("Structured() confidence = %v, want %v", confidence, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestStructuredUnmodified(t *testing.T) {
+	addr := qrbill.Address{
+		AdrTp:            qrbill.AddressTypeStructured,
+		Name:             "Legalize it",
+		StrtNmOrAdrLine1: "Quellenstrasse",
+		BldgNbOrAdrLine2: "25",
+		PstCd:            "8005",
+		TwnNm:            "Zürich",
+		Ctry:             "CH",
+	}
+	if got, confidence := addr.Structured(); got != addr || confidence != qrbill.ConfidenceHigh {
+		t.Errorf("Structured() = %+v, %v, want %+v, high", got, confidence, addr)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed countries.txt
+var countriesTxt string
+
+// countries maps ISO 3166-1 alpha-2 country codes to their names.
+var countries = func() map[string]string {
+	m := make(map[string]string)
+	for _, line := range strings.Split(countriesTxt, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		code, name, _ := strings.Cut(line, "\t")
+		m[code] = name
+	}
+	return m
+}()
+
+// CountryName returns the English short name of the ISO 3166-1 alpha-2
+// country code, e.g. Switzerland for CH, and whether code is a valid code.
+func CountryName(code string) (string, bool) {
+	name, ok := countries[code]
+	return name, ok
+}
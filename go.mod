@@ -7,10 +7,10 @@ require (
 	github.com/davecgh/go-spew v1.1.1
 	github.com/makiuchi-d/gozxing v0.1.1
 	github.com/mattn/go-isatty v0.0.20
+	golang.org/x/text v0.14.0
 )
 
 require (
 	golang.org/x/sys v0.16.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/xerrors v0.0.0-20231012003039-104605ab7028 // indirect
 )
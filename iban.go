@@ -0,0 +1,73 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ibanLen is the length of Swiss and Liechtenstein IBANs.
+const ibanLen = 21
+
+// QR-IBANs are identified by an institution identification (QR-IID) in this
+// range, see section 3.1 of the Implementation Guidelines.
+const (
+	qrIIDMin = 30000
+	qrIIDMax = 31999
+)
+
+// ValidateIBAN returns an error if iban is not a valid IBAN for QR-bills: only
+// Swiss (CH) and Liechtenstein (LI) IBANs with 21 characters and a correct
+// checksum are permitted. Whitespace is ignored.
+func ValidateIBAN(iban string) error {
+	iban = strings.ToUpper(stripSpaces(iban))
+	if len(iban) != ibanLen {
+		return fmt.Errorf("IBAN %q has invalid length: got %d, want %d", iban, len(iban), ibanLen)
+	}
+	if cc := iban[:2]; cc != "CH" && cc != "LI" {
+		return fmt.Errorf("IBAN %q has country code %s, want CH or LI", iban, cc)
+	}
+	if !isDigits(iban[2:4]) {
+		return fmt.Errorf("IBAN %q has non-numeric check digits", iban)
+	}
+	if !isDigits(iban[4:9]) {
+		return fmt.Errorf("IBAN %q has non-numeric institution identification", iban)
+	}
+	if !isAlphanumeric(iban[9:]) {
+		return fmt.Errorf("IBAN %q must only contain alphanumeric characters", iban)
+	}
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return fmt.Errorf("IBAN %q has invalid check digits", iban)
+	}
+	return nil
+}
+
+// IsQRIBAN reports whether iban is a valid QR-IBAN, i.e. a valid IBAN whose
+// institution identification (positions 5 to 9) is in the range 30000 to
+// 31999. A QR-IBAN must be used with a QR reference (QRR), any other IBAN
+// with a Creditor Reference (SCOR) or without reference (NON).
+func IsQRIBAN(iban string) bool {
+	if ValidateIBAN(iban) != nil {
+		return false
+	}
+	iban = stripSpaces(iban)
+	iid, err := strconv.Atoi(iban[4:9])
+	if err != nil {
+		return false
+	}
+	return iid >= qrIIDMin && iid <= qrIIDMax
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Standard 14 fonts for which metrics are included.
+const (
+	Helvetica     = "Helvetica"
+	HelveticaBold = "Helvetica-Bold"
+)
+
+// widths contains the glyph widths (in 1/1000 of the font size) of the
+// characters 0x20 to 0xFF in WinAnsiEncoding, from the Adobe Font Metrics
+// files of the standard 14 fonts.
+var widths = map[string]*[224]int{
+	Helvetica: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // 0x20
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0x30
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // 0x40
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // 0x50
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // 0x60
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, 350, // 0x70
+		556, 350, 222, 556, 333, 1000, 556, 556, 333, 1000, 667, 333, 1000, 350, 611, 350, // 0x80
+		350, 222, 222, 333, 333, 350, 556, 1000, 333, 1000, 500, 333, 944, 350, 500, 667, // 0x90
+		278, 333, 556, 556, 556, 556, 260, 556, 333, 737, 370, 556, 584, 333, 737, 333, // 0xA0
+		400, 584, 333, 333, 333, 556, 537, 278, 333, 333, 365, 556, 834, 834, 834, 611, // 0xB0
+		667, 667, 667, 667, 667, 667, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278, // 0xC0
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611, // 0xD0
+		556, 556, 556, 556, 556, 556, 889, 500, 556, 556, 556, 556, 278, 278, 278, 278, // 0xE0
+		556, 556, 556, 556, 556, 556, 556, 584, 611, 556, 556, 556, 556, 500, 556, 500, // 0xF0
+	},
+	HelveticaBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // 0x20
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0x30
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // 0x40
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // 0x50
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // 0x60
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, 350, // 0x70
+		556, 350, 278, 556, 500, 1000, 556, 556, 333, 1000, 667, 333, 1000, 350, 611, 350, // 0x80
+		350, 278, 278, 500, 500, 350, 556, 1000, 333, 1000, 556, 333, 944, 350, 500, 667, // 0x90
+		278, 333, 556, 556, 556, 556, 280, 556, 333, 737, 370, 556, 584, 333, 737, 333, // 0xA0
+		400, 584, 333, 333, 333, 611, 556, 278, 333, 333, 365, 556, 834, 834, 834, 611, // 0xB0
+		722, 722, 722, 722, 722, 722, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278, // 0xC0
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611, // 0xD0
+		556, 556, 556, 556, 556, 556, 889, 556, 556, 556, 556, 556, 278, 278, 278, 278, // 0xE0
+		611, 611, 611, 611, 611, 611, 611, 584, 611, 611, 611, 611, 611, 556, 611, 556, // 0xF0
+	},
+}
+
+// TextFont is implemented by the fonts which can be used for drawing text:
+// Font and TrueTypeFont.
+type TextFont interface {
+	Object
+
+	// Width returns the width of s when set in size, in the same unit as
+	// size.
+	Width(s string, size float64) float64
+
+	// Text returns s as a PDF string for use with the Tj operator.
+	Text(s string) string
+}
+
+// ShowText returns a text object which draws s in f and size (in pt), with
+// its baseline starting at (x, y) in pt. f must be in the resources of the
+// page.
+func ShowText(f TextFont, size, x, y float64, s string) string {
+	return fmt.Sprintf("BT /%s %s Tf %s %s Td %s Tj ET\n",
+		f.Name(), Number(size), Number(x), Number(y), f.Text(s))
+}
+
+// Font represents a PDF font object of one of the standard 14 fonts, which
+// PDF viewers provide, using WinAnsiEncoding (Windows code page 1252).
+type Font struct {
+	Common
+
+	// BaseFont is Helvetica or HelveticaBold.
+	BaseFont string
+}
+
+// Objects implements Object.
+func (f *Font) Objects() []Object {
+	return []Object{f}
+}
+
+// Encode implements Object.
+func (f *Font) Encode(w io.Writer, ids map[string]ObjectID) error {
+	_, err := fmt.Fprintf(w, `
+%d 0 obj
+<<
+  /Type /Font
+  /Subtype /Type1
+  /BaseFont /%s
+  /Encoding /WinAnsiEncoding
+>>
+endobj`, int(f.ID), f.BaseFont)
+	return err
+}
+
+// winAnsi encodes s in WinAnsiEncoding. Characters which cannot be encoded
+// are replaced by their base character where possible (e.g. č by c), and by
+// a question mark otherwise.
+func winAnsi(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if c, ok := charmap.Windows1252.EncodeRune(r); ok && c >= 0x20 {
+			b = append(b, c)
+			continue
+		}
+		base := []rune(norm.NFD.String(string(r)))[0]
+		if c, ok := charmap.Windows1252.EncodeRune(base); ok && c >= 0x20 {
+			b = append(b, c)
+			continue
+		}
+		b = append(b, '?')
+	}
+	return b
+}
+
+// Width implements TextFont.
+func (f *Font) Width(s string, size float64) float64 {
+	w := widths[f.BaseFont]
+	var total int
+	for _, c := range winAnsi(s) {
+		total += w[c-0x20]
+	}
+	return float64(total) * size / 1000
+}
+
+// literalEscaper escapes the characters with special meaning in PDF literal
+// strings, see section “7.3.4.2 Literal Strings”.
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+
+// Text implements TextFont.
+func (f *Font) Text(s string) string {
+	return "(" + literalEscaper.Replace(string(winAnsi(s))) + ")"
+}
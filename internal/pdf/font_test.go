@@ -0,0 +1,287 @@
+package pdf_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stapelberg/qrbill/internal/pdf"
+)
+
+func TestFont(t *testing.T) {
+	f := &pdf.Font{
+		Common:   pdf.Common{ObjectName: "helvetica"},
+		BaseFont: pdf.Helvetica,
+	}
+	// A: 667, W: 944, ü: 556 (from Helvetica.afm)
+	if got, want := f.Width("AWü", 10), 21.67; got != want {
+		t.Errorf("Width() = %v, want %v", got, want)
+	}
+	// č falls back to c, ș to s, and € is part of WinAnsiEncoding:
+	if got, want := f.Text(`(č) ș €\`), "(\\(c\\) s \x80\\\\)"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	if got, want := pdf.ShowText(f, 10, 14.1732, 28, "Hi"), "BT /helvetica 10 Tf 14.173 28 Td (Hi) Tj ET\n"; got != want {
+		t.Errorf("ShowText() = %q, want %q", got, want)
+	}
+	// Characters without base character in WinAnsiEncoding:
+	if got, want := f.Text("中"), "(?)"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	bold := &pdf.Font{
+		Common:   pdf.Common{ObjectName: "helveticabold"},
+		BaseFont: pdf.HelveticaBold,
+	}
+	// A: 722, W: 944, ü: 611 (from Helvetica-Bold.afm)
+	if got, want := bold.Width("AWü", 10), 22.77; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Width() = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"/Subtype /Type1\n", "/BaseFont /Helvetica\n", "/Encoding /WinAnsiEncoding\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Encode() does not contain %q", want)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	for _, tt := range []struct {
+		n    float64
+		want string
+	}{
+		{2, "2"},
+		{841.8897, "841.89"},
+		{14.17325, "14.173"},
+		{-0.0001, "0"},
+		{-3.5, "-3.5"},
+	} {
+		if got := pdf.Number(tt.n); got != tt.want {
+			t.Errorf("Number(%v) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPageResources(t *testing.T) {
+	font := &pdf.Font{
+		Common:   pdf.Common{ObjectName: "helvetica"},
+		BaseFont: pdf.Helvetica,
+	}
+	page := &pdf.Page{
+		Common:    pdf.Common{ObjectName: "page0"},
+		MediaBox:  [4]float64{0, 0, 595.2756, 841.8897},
+		Resources: []pdf.Object{font},
+		Contents: []pdf.Object{
+			&pdf.Common{
+				ObjectName: "content",
+				Stream:     []byte(pdf.ShowText(font, 12, 10, 10, "Hi")),
+			},
+		},
+		Parent: "pages",
+	}
+	doc := &pdf.Catalog{
+		Common: pdf.Common{ObjectName: "catalog"},
+		Pages: &pdf.Pages{
+			Common: pdf.Common{ObjectName: "pages"},
+			Kids:   []pdf.Object{page},
+		},
+	}
+	var buf bytes.Buffer
+	if err := pdf.NewEncoder(&buf).Encode(doc, &pdf.DocumentInfo{Common: pdf.Common{ObjectName: "info"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"/Font <<\n/helvetica 4 0 R\n    >>",
+		"/MediaBox [ 0 0 595.276 841.89 ]",
+		"BT /helvetica 12 Tf 10 10 Td (Hi) Tj ET",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Encode() does not contain %q", want)
+		}
+	}
+}
+
+// ttfTable is a table of a synthetic TrueType font.
+type ttfTable struct {
+	tag  string
+	data []byte
+}
+
+// testTrueType returns a minimal TrueType font (without outlines) with the
+// glyphs .notdef, A, B and ș, in 2000 units per em.
+func testTrueType(modify func([]ttfTable) []ttfTable) []byte {
+	be := func(values ...interface{}) []byte {
+		var buf bytes.Buffer
+		for _, v := range values {
+			binary.Write(&buf, binary.BigEndian, v)
+		}
+		return buf.Bytes()
+	}
+	head := make([]byte, 54)
+	copy(head[18:], be(uint16(2000)))
+	copy(head[36:], be(int16(-100), int16(-400), int16(1800), int16(1600)))
+	hhea := make([]byte, 36)
+	copy(hhea[4:], be(int16(1500), int16(-500)))
+	copy(hhea[34:], be(uint16(3))) // numberOfHMetrics
+	// Format 4 with three segments: A–B using idDelta, ș using idRangeOffset
+	// and the final 0xFFFF segment.
+	cmap := be(uint16(0), uint16(1), uint16(3), uint16(1), uint32(12),
+		uint16(4), uint16(48), uint16(0),
+		uint16(6), uint16(4), uint16(1), uint16(2), // segCountX2, searchRange, …
+		uint16(0x42), uint16(0x219), uint16(0xffff), // endCode
+		uint16(0),
+		uint16(0x41), uint16(0x219), uint16(0xffff), // startCode
+		int16(1-0x41), int16(0), int16(1), // idDelta
+		uint16(0), uint16(4), uint16(0), // idRangeOffset
+		uint16(3)) // glyphIdArray
+	name := be(uint16(0), uint16(1), uint16(18),
+		uint16(3), uint16(1), uint16(0x409), uint16(6), uint16(18), uint16(0),
+		[]uint16{'T', 'e', 's', 't', ' ', 'S', 'a', 'n', 's'})
+	tables := []ttfTable{
+		{"head", head},
+		{"hhea", hhea},
+		{"maxp", be(uint32(0x00005000), uint16(4))},
+		// B and ș share the last advance width:
+		{"hmtx", be(uint16(1000), int16(0), uint16(1200), int16(0), uint16(1400), int16(0))},
+		{"cmap", cmap},
+		{"post", be(uint32(0x00030000), int32(-12<<16), int16(0), int16(0), uint32(0))},
+		{"name", name},
+	}
+	if modify != nil {
+		tables = modify(tables)
+	}
+
+	font := be(uint32(0x00010000), uint16(len(tables)), uint16(0), uint16(0), uint16(0))
+	offset := len(font) + 16*len(tables)
+	var data []byte
+	for _, table := range tables {
+		font = append(font, table.tag...)
+		font = append(font, be(uint32(0), uint32(offset+len(data)), uint32(len(table.data)))...)
+		data = append(data, table.data...)
+	}
+	return append(font, data...)
+}
+
+func TestTrueTypeFont(t *testing.T) {
+	f, err := pdf.ParseTrueType(testTrueType(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.ObjectName = "regular"
+	if got, want := f.Width("AB", 10), 13.0; got != want {
+		t.Errorf("Width(AB) = %v, want %v", got, want)
+	}
+	if got, want := f.Width("ș", 10), 7.0; got != want {
+		t.Errorf("Width(ș) = %v, want %v", got, want)
+	}
+	// Ä falls back to A, C is missing (.notdef):
+	if got, want := f.Text("AșÄC"), "<0001000300010000>"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	page := &pdf.Page{
+		Common:    pdf.Common{ObjectName: "page0"},
+		MediaBox:  [4]float64{0, 0, 100, 100},
+		Resources: []pdf.Object{f},
+		Contents: []pdf.Object{
+			&pdf.Common{
+				ObjectName: "content",
+				Stream:     []byte(pdf.ShowText(f, 12, 10, 10, "B")),
+			},
+		},
+		Parent: "pages",
+	}
+	doc := &pdf.Catalog{
+		Common: pdf.Common{ObjectName: "catalog"},
+		Pages: &pdf.Pages{
+			Common: pdf.Common{ObjectName: "pages"},
+			Kids:   []pdf.Object{page},
+		},
+	}
+	var buf bytes.Buffer
+	if err := pdf.NewEncoder(&buf).Encode(doc, &pdf.DocumentInfo{Common: pdf.Common{ObjectName: "info"}}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"/Font <<\n/regular 4 0 R\n",
+		"/Subtype /Type0\n  /BaseFont /TestSans\n  /Encoding /Identity-H\n  /DescendantFonts [5 0 R]\n  /ToUnicode 8 0 R\n",
+		"/FontDescriptor 6 0 R\n  /DW 500\n  /W [1 [600] 2 [700] 3 [700]]\n",
+		"/FontBBox [-50 -200 900 800]\n  /ItalicAngle -12\n  /Ascent 750\n  /Descent -250\n",
+		"/FontFile2 7 0 R\n",
+		"/Length1 " + strconv.Itoa(len(testTrueType(nil))) + "\n  /Filter /FlateDecode\n",
+		"3 beginbfchar\n<0001> <0041>\n<0002> <0042>\n<0003> <0219>\nendbfchar\n",
+		"BT /regular 12 Tf 10 10 Td <0002> Tj ET",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Encode() does not contain %q", want)
+		}
+	}
+}
+
+func TestParseTrueTypeErrors(t *testing.T) {
+	without := func(tag string) func([]ttfTable) []ttfTable {
+		return func(tables []ttfTable) []ttfTable {
+			var result []ttfTable
+			for _, table := range tables {
+				if table.tag != tag {
+					result = append(result, table)
+				}
+			}
+			return result
+		}
+	}
+	for _, tt := range []struct {
+		desc string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "not a TrueType font"},
+		{"CFF", []byte("OTTO\x00\x00"), "CFF outlines are not supported"},
+		{"missing cmap", testTrueType(without("cmap")), `required table "cmap" missing`},
+		{"truncated", testTrueType(nil)[:200], "out of bounds"},
+		{
+			desc: "restricted license",
+			data: testTrueType(func(tables []ttfTable) []ttfTable {
+				os2 := make([]byte, 78)
+				os2[9] = 0x02 // fsType
+				return append(tables, ttfTable{"OS/2", os2})
+			}),
+			want: "does not permit embedding",
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := pdf.ParseTrueType(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTrueType() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// Mappings to glyph ids beyond the glyphs declared in the maxp table are
+	// ignored:
+	f, err := pdf.ParseTrueType(testTrueType(func(tables []ttfTable) []ttfTable {
+		for idx, table := range tables {
+			if table.tag == "maxp" {
+				tables[idx].data = []byte{0, 0, 0x50, 0, 0, 3} // numGlyphs: 3 (without ș)
+			}
+		}
+		return tables
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.Width("ș", 10), 5.0; got != want {
+		t.Errorf("Width(ș) = %v, want %v (.notdef)", got, want)
+	}
+	if got, want := f.Text("Aș"), "<00010000>"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
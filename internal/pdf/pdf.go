@@ -13,7 +13,9 @@
 // limitations under the License.
 
 // Package pdf implements a minimal PDF 1.7 writer, just functional enough to
-// create a PDF file containing a QR code encoded as rectangles.
+// create a PDF file containing a QR code encoded as rectangles, and the text
+// of a payment slip in one of the standard 14 fonts or an embedded TrueType
+// font.
 //
 // It follows the standard “PDF 32000-1:2008 PDF 1.7”:
 // https://www.adobe.com/content/dam/Adobe/en/devnet/acrobat/pdfs/PDF32000_2008.pdf
@@ -22,6 +24,8 @@ package pdf
 import (
 	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -181,11 +185,15 @@ endobj`, int(p.ID), p.Kids, len(p.Kids))
 	return err
 }
 
-// Page represents a PDF page object with size DIN A4
+// Page represents a PDF page object.
 type Page struct {
 	Common
 
-	Resources []Object // Image
+	// MediaBox is the page size in points (1/72 inch) as lower-left x,
+	// lower-left y, upper-right x and upper-right y.
+	MediaBox [4]float64
+
+	Resources []Object // Image, Font or TrueTypeFont
 	Contents  []Object // Common (streams)
 
 	// Parent contains the human-readable name of the parent object,
@@ -207,27 +215,49 @@ func (p *Page) Objects() []Object {
 
 // Encode implements Object.
 func (p *Page) Encode(w io.Writer, ids map[string]ObjectID) error {
-	xObjects := make([]string, len(p.Resources))
-	for idx, o := range p.Resources {
-		xObjects[idx] = fmt.Sprintf("/%s %v", o.Name(), ids[o.Name()])
+	var xObjects, fonts []string
+	for _, o := range p.Resources {
+		entry := fmt.Sprintf("/%s %v", o.Name(), ids[o.Name()])
+		if _, ok := o.(TextFont); ok {
+			fonts = append(fonts, entry)
+		} else {
+			xObjects = append(xObjects, entry)
+		}
+	}
+	mediaBox := make([]string, len(p.MediaBox))
+	for idx, n := range p.MediaBox {
+		mediaBox[idx] = Number(n)
 	}
 	_, err := fmt.Fprintf(w, `
 %d 0 obj
 <<
   /Resources <<
     /XObject <<
+%s
+    >>
+    /Font <<
 %s
     >>
   >>
   /Contents %v
   /Parent %v
   /Type /Page
-  /MediaBox [ 0 0 152 152 ]
+  /MediaBox [ %s ]
 >>
-endobj`, int(p.ID), strings.Join(xObjects, "\n"), p.Contents, ids[p.Parent])
+endobj`, int(p.ID), strings.Join(xObjects, "\n"), strings.Join(fonts, "\n"), p.Contents, ids[p.Parent], strings.Join(mediaBox, " "))
 	return err
 }
 
+// Number formats n for use in PDF content streams and objects, with at most
+// three decimal places.
+func Number(n float64) string {
+	n = math.Round(n*1000) / 1000
+	if n == 0 {
+		n = 0 // avoid printing negative zero
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
 type countingWriter struct {
 	cnt int
 	w   io.Writer
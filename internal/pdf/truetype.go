@@ -0,0 +1,514 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TrueTypeFont represents an embedded TrueType font. It is encoded as a
+// composite font (Type0) with Identity-H encoding, i.e. text is encoded as
+// glyph ids, so that all characters of the font can be used. See section
+// “9.7 Composite Fonts”.
+//
+// The font file is embedded as a whole, without subsetting.
+type TrueTypeFont struct {
+	Common
+
+	data           []byte
+	postScriptName string
+	unitsPerEm     int
+	bbox           [4]int
+	ascent         int
+	descent        int
+	capHeight      int
+	italicAngle    float64
+	fixedPitch     bool
+	advances       []int
+	glyphs         map[rune]uint16
+
+	// used maps the glyph ids used by Text to their characters, for the
+	// widths and the ToUnicode CMap.
+	used map[uint16]rune
+}
+
+// ttfReader reads big-endian values from a font table, recording the first
+// out-of-bounds access.
+type ttfReader struct {
+	b   []byte
+	err error
+}
+
+func (r *ttfReader) u16(off int) int {
+	if off < 0 || off+2 > len(r.b) {
+		r.err = errors.New("truncated table")
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(r.b[off:]))
+}
+
+func (r *ttfReader) i16(off int) int {
+	return int(int16(r.u16(off)))
+}
+
+func (r *ttfReader) u32(off int) int {
+	if off < 0 || off+4 > len(r.b) {
+		r.err = errors.New("truncated table")
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(r.b[off:]))
+}
+
+// ParseTrueType parses the TrueType font file data (.ttf) for embedding.
+func ParseTrueType(data []byte) (*TrueTypeFont, error) {
+	file := &ttfReader{b: data}
+	switch version := file.u32(0); version {
+	case 0x00010000, 0x74727565: // 'true'
+	case 0x4f54544f: // 'OTTO'
+		return nil, errors.New("OpenType fonts with CFF outlines are not supported")
+	default:
+		return nil, fmt.Errorf("not a TrueType font (version %#x)", version)
+	}
+	tables := make(map[string]*ttfReader)
+	numTables := file.u16(4)
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errors.New("truncated table directory")
+		}
+		tag := string(data[rec : rec+4])
+		off, length := file.u32(rec+8), file.u32(rec+12)
+		if off+length > len(data) {
+			return nil, fmt.Errorf("table %q out of bounds", tag)
+		}
+		tables[tag] = &ttfReader{b: data[off : off+length]}
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("required table %q missing", tag)
+		}
+	}
+
+	f := &TrueTypeFont{
+		data:   data,
+		glyphs: make(map[rune]uint16),
+		used:   make(map[uint16]rune),
+	}
+
+	head := tables["head"]
+	f.unitsPerEm = head.u16(18)
+	f.bbox = [4]int{head.i16(36), head.i16(38), head.i16(40), head.i16(42)}
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("head: unitsPerEm must not be 0")
+	}
+
+	hhea := tables["hhea"]
+	f.ascent = hhea.i16(4)
+	f.descent = hhea.i16(6)
+	f.capHeight = f.ascent
+	numberOfHMetrics := hhea.u16(34)
+
+	numGlyphs := tables["maxp"].u16(4)
+	if numberOfHMetrics == 0 || numberOfHMetrics > numGlyphs {
+		return nil, fmt.Errorf("hhea: invalid numberOfHMetrics %d for %d glyphs", numberOfHMetrics, numGlyphs)
+	}
+	hmtx := tables["hmtx"]
+	f.advances = make([]int, numGlyphs)
+	for gid := range f.advances {
+		if gid < numberOfHMetrics {
+			f.advances[gid] = hmtx.u16(4 * gid)
+		} else {
+			f.advances[gid] = f.advances[numberOfHMetrics-1]
+		}
+	}
+
+	if err := f.parseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+
+	if post := tables["post"]; post != nil {
+		f.italicAngle = float64(int32(post.u32(4))) / 65536
+		f.fixedPitch = post.u32(12) != 0
+		if post.err != nil {
+			return nil, fmt.Errorf("post: %v", post.err)
+		}
+	}
+
+	if os2 := tables["OS/2"]; os2 != nil {
+		// Restricted License embedding: the font must not be embedded.
+		if fsType := os2.u16(8); fsType&0xf == 0x2 {
+			return nil, errors.New("the font license does not permit embedding")
+		}
+		if os2.u16(0) >= 2 && len(os2.b) >= 90 {
+			f.capHeight = os2.i16(88)
+		}
+		if os2.err != nil {
+			return nil, fmt.Errorf("OS/2: %v", os2.err)
+		}
+	}
+
+	if name := tables["name"]; name != nil {
+		f.postScriptName = parsePostScriptName(name)
+	}
+
+	for tag, r := range map[string]*ttfReader{"head": head, "hhea": hhea, "maxp": tables["maxp"], "hmtx": hmtx} {
+		if r.err != nil {
+			return nil, fmt.Errorf("%s: %v", tag, r.err)
+		}
+	}
+	return f, nil
+}
+
+// parseCmap reads the Unicode character to glyph mapping, from a subtable
+// in format 12 (full Unicode) or 4 (Basic Multilingual Plane).
+func (f *TrueTypeFont) parseCmap(cmap *ttfReader) error {
+	best, bestFormat := -1, 0
+	for i := 0; i < cmap.u16(2); i++ {
+		rec := 4 + 8*i
+		platform, encoding, off := cmap.u16(rec), cmap.u16(rec+2), cmap.u32(rec+4)
+		unicode := platform == 0 || platform == 3 && (encoding == 1 || encoding == 10)
+		if format := cmap.u16(off); unicode && (format == 4 || format == 12) && format > bestFormat {
+			best, bestFormat = off, format
+		}
+	}
+	if cmap.err != nil {
+		return fmt.Errorf("cmap: %v", cmap.err)
+	}
+	if best == -1 {
+		return errors.New("cmap: no Unicode subtable in format 4 or 12")
+	}
+
+	// add records a mapping, ignoring glyph ids beyond the glyphs of the font
+	// (as declared in the maxp table), which would be invalid references.
+	add := func(c rune, gid int) {
+		if gid > 0 && gid < len(f.advances) {
+			f.glyphs[c] = uint16(gid)
+		}
+	}
+	if bestFormat == 12 {
+		for i := 0; i < cmap.u32(best+12); i++ {
+			group := best + 16 + 12*i
+			start, end, gid := cmap.u32(group), cmap.u32(group+4), cmap.u32(group+8)
+			if cmap.err != nil || end-start > len(f.advances) {
+				break
+			}
+			for c := start; c <= end; c++ {
+				add(rune(c), gid+c-start)
+			}
+		}
+	} else {
+		segCount := cmap.u16(best+6) / 2
+		endCodes := best + 14
+		startCodes := endCodes + 2*segCount + 2
+		idDeltas := startCodes + 2*segCount
+		idRangeOffsets := idDeltas + 2*segCount
+		for seg := 0; seg < segCount; seg++ {
+			start, end := cmap.u16(startCodes+2*seg), cmap.u16(endCodes+2*seg)
+			delta, rangeOffset := cmap.u16(idDeltas+2*seg), cmap.u16(idRangeOffsets+2*seg)
+			for c := start; c <= end && c != 0xffff; c++ {
+				gid := (c + delta) & 0xffff
+				if rangeOffset != 0 {
+					// The offset is relative to the position of the
+					// idRangeOffset value itself:
+					gid = cmap.u16(idRangeOffsets + 2*seg + rangeOffset + 2*(c-start))
+					if gid != 0 {
+						gid = (gid + delta) & 0xffff
+					}
+				}
+				add(rune(c), gid)
+			}
+		}
+	}
+	if cmap.err != nil {
+		return fmt.Errorf("cmap: %v", cmap.err)
+	}
+	return nil
+}
+
+// parsePostScriptName returns the PostScript name (name id 6) from the name
+// table, or the empty string.
+func parsePostScriptName(name *ttfReader) string {
+	count, storage := name.u16(2), name.u16(4)
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		platform, id := name.u16(rec), name.u16(rec+6)
+		length, off := name.u16(rec+8), name.u16(rec+10)
+		if id != 6 || name.err != nil {
+			continue
+		}
+		start := storage + off
+		if start+length > len(name.b) {
+			return ""
+		}
+		b := name.b[start : start+length]
+		if platform == 0 || platform == 3 {
+			// UTF-16BE
+			var s []rune
+			for j := 0; j+1 < len(b); j += 2 {
+				s = append(s, rune(binary.BigEndian.Uint16(b[j:])))
+			}
+			return sanitizeName(string(s))
+		}
+		return sanitizeName(string(b))
+	}
+	return ""
+}
+
+// sanitizeName removes all characters which require escaping in PDF names.
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || strings.ContainsRune("/()<>[]{}%#", r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// glyph returns the glyph id for r and the character it represents, falling
+// back to the base character (e.g. c for č) and to the .notdef glyph.
+func (f *TrueTypeFont) glyph(r rune) (uint16, rune) {
+	if gid, ok := f.glyphs[r]; ok {
+		return gid, r
+	}
+	base := []rune(norm.NFD.String(string(r)))[0]
+	if gid, ok := f.glyphs[base]; ok {
+		return gid, base
+	}
+	return 0, r
+}
+
+// width returns the advance width of gid in 1/1000 of the font size.
+func (f *TrueTypeFont) width(gid uint16) float64 {
+	return float64(f.advances[gid]) * 1000 / float64(f.unitsPerEm)
+}
+
+// Width implements TextFont.
+func (f *TrueTypeFont) Width(s string, size float64) float64 {
+	var total float64
+	for _, r := range s {
+		gid, _ := f.glyph(r)
+		total += f.width(gid)
+	}
+	return total * size / 1000
+}
+
+// Text implements TextFont.
+func (f *TrueTypeFont) Text(s string) string {
+	var b strings.Builder
+	b.WriteString("<")
+	for _, r := range s {
+		gid, mapped := f.glyph(r)
+		if gid != 0 {
+			f.used[gid] = mapped
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// baseFont returns the PostScript name of the font, as used for /BaseFont.
+func (f *TrueTypeFont) baseFont() string {
+	if f.postScriptName != "" {
+		return f.postScriptName
+	}
+	return f.Name()
+}
+
+// usedGlyphs returns the glyph ids used by Text, in ascending order.
+func (f *TrueTypeFont) usedGlyphs() []uint16 {
+	gids := make([]uint16, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, gid)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	return gids
+}
+
+// fontPart is an object which is encoded as part of a TrueTypeFont.
+type fontPart struct {
+	Common
+	encode func(w io.Writer, id ObjectID, ids map[string]ObjectID) error
+}
+
+// Objects implements Object.
+func (p *fontPart) Objects() []Object {
+	return []Object{p}
+}
+
+// Encode implements Object.
+func (p *fontPart) Encode(w io.Writer, ids map[string]ObjectID) error {
+	return p.encode(w, p.ID, ids)
+}
+
+// Objects implements Object.
+func (f *TrueTypeFont) Objects() []Object {
+	return []Object{
+		f,
+		&fontPart{Common: Common{ObjectName: f.Name() + "-cidfont"}, encode: f.encodeCIDFont},
+		&fontPart{Common: Common{ObjectName: f.Name() + "-descriptor"}, encode: f.encodeDescriptor},
+		&fontPart{Common: Common{ObjectName: f.Name() + "-file"}, encode: f.encodeFile},
+		&fontPart{Common: Common{ObjectName: f.Name() + "-tounicode"}, encode: f.encodeToUnicode},
+	}
+}
+
+// Encode implements Object.
+func (f *TrueTypeFont) Encode(w io.Writer, ids map[string]ObjectID) error {
+	_, err := fmt.Fprintf(w, `
+%d 0 obj
+<<
+  /Type /Font
+  /Subtype /Type0
+  /BaseFont /%s
+  /Encoding /Identity-H
+  /DescendantFonts [%v]
+  /ToUnicode %v
+>>
+endobj`, int(f.ID), f.baseFont(), ids[f.Name()+"-cidfont"], ids[f.Name()+"-tounicode"])
+	return err
+}
+
+func (f *TrueTypeFont) encodeCIDFont(w io.Writer, id ObjectID, ids map[string]ObjectID) error {
+	var widths []string
+	for _, gid := range f.usedGlyphs() {
+		widths = append(widths, fmt.Sprintf("%d [%s]", gid, Number(f.width(gid))))
+	}
+	_, err := fmt.Fprintf(w, `
+%d 0 obj
+<<
+  /Type /Font
+  /Subtype /CIDFontType2
+  /BaseFont /%s
+  /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >>
+  /FontDescriptor %v
+  /DW %s
+  /W [%s]
+  /CIDToGIDMap /Identity
+>>
+endobj`, int(id), f.baseFont(), ids[f.Name()+"-descriptor"], Number(f.width(0)), strings.Join(widths, " "))
+	return err
+}
+
+func (f *TrueTypeFont) encodeDescriptor(w io.Writer, id ObjectID, ids map[string]ObjectID) error {
+	scale := func(v int) string {
+		return Number(float64(v) * 1000 / float64(f.unitsPerEm))
+	}
+	// Flags (section “9.8.2 Font Descriptor Flags”): Nonsymbolic, FixedPitch
+	flags := 1 << 5
+	if f.fixedPitch {
+		flags |= 1 << 0
+	}
+	_, err := fmt.Fprintf(w, `
+%d 0 obj
+<<
+  /Type /FontDescriptor
+  /FontName /%s
+  /Flags %d
+  /FontBBox [%s %s %s %s]
+  /ItalicAngle %s
+  /Ascent %s
+  /Descent %s
+  /CapHeight %s
+  /StemV 80
+  /FontFile2 %v
+>>
+endobj`, int(id), f.baseFont(), flags,
+		scale(f.bbox[0]), scale(f.bbox[1]), scale(f.bbox[2]), scale(f.bbox[3]),
+		Number(f.italicAngle), scale(f.ascent), scale(f.descent), scale(f.capHeight),
+		ids[f.Name()+"-file"])
+	return err
+}
+
+func (f *TrueTypeFont) encodeFile(w io.Writer, id ObjectID, ids map[string]ObjectID) error {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(f.data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, `
+%d 0 obj
+<<
+  /Length %d
+  /Length1 %d
+  /Filter /FlateDecode
+>>
+stream
+%s
+endstream
+endobj`, int(id), buf.Len(), len(f.data), buf.Bytes())
+	return err
+}
+
+// encodeToUnicode writes a CMap which maps the used glyph ids to Unicode,
+// so that text can be extracted (e.g. copied) from the PDF file. See section
+// “9.10.3 ToUnicode CMaps”.
+func (f *TrueTypeFont) encodeToUnicode(w io.Writer, id ObjectID, ids map[string]ObjectID) error {
+	var cmap strings.Builder
+	cmap.WriteString(`/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+`)
+	gids := f.usedGlyphs()
+	// At most 100 mappings are permitted per bfchar block:
+	for len(gids) > 0 {
+		n := len(gids)
+		if n > 100 {
+			n = 100
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", n)
+		for _, gid := range gids[:n] {
+			fmt.Fprintf(&cmap, "<%04X> <", gid)
+			for _, u := range utf16.Encode([]rune{f.used[gid]}) {
+				fmt.Fprintf(&cmap, "%04X", u)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+		gids = gids[n:]
+	}
+	cmap.WriteString(`endcmap
+CMapName currentdict /CMapResource defineresource pop
+end
+end`)
+	_, err := fmt.Fprintf(w, `
+%d 0 obj
+<<
+  /Length %d
+>>
+stream
+%s
+endstream
+endobj`, int(id), cmap.Len(), cmap.String())
+	return err
+}
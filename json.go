@@ -0,0 +1,245 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONSchemaVersion is the version of the JSON representation produced by
+// QRCH.MarshalJSON. Field names are stable within a schema version, see
+// qrbill.schema.json for the JSON Schema document.
+const JSONSchemaVersion = 1
+
+// decodeJSON decodes data into v, rejecting unknown fields.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+type addressJSON struct {
+	Type           AddressType `json:"type"`
+	Name           string      `json:"name,omitempty"`
+	Street         string      `json:"street,omitempty"`
+	BuildingNumber string      `json:"buildingNumber,omitempty"`
+	AddressLine1   string      `json:"addressLine1,omitempty"`
+	AddressLine2   string      `json:"addressLine2,omitempty"`
+	PostalCode     string      `json:"postalCode,omitempty"`
+	Town           string      `json:"town,omitempty"`
+	Country        string      `json:"country,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Structured addresses (type S) use
+// the fields street and buildingNumber, combined addresses (type K) use
+// addressLine1 and addressLine2.
+func (a Address) MarshalJSON() ([]byte, error) {
+	j := addressJSON{
+		Type:       a.AdrTp,
+		Name:       a.Name,
+		PostalCode: a.PstCd,
+		Town:       a.TwnNm,
+		Country:    a.Ctry,
+	}
+	if a.AdrTp == AddressTypeCombined {
+		j.AddressLine1 = a.StrtNmOrAdrLine1
+		j.AddressLine2 = a.BldgNbOrAdrLine2
+	} else {
+		j.Street = a.StrtNmOrAdrLine1
+		j.BuildingNumber = a.BldgNbOrAdrLine2
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var j addressJSON
+	if err := decodeJSON(data, &j); err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+	*a = Address{
+		AdrTp: j.Type,
+		Name:  j.Name,
+		PstCd: j.PostalCode,
+		TwnNm: j.Town,
+		Ctry:  j.Country,
+	}
+	switch j.Type {
+	case AddressTypeStructured:
+		if j.AddressLine1 != "" || j.AddressLine2 != "" {
+			return fmt.Errorf("address: addressLine1 and addressLine2 are only permitted for type %s", AddressTypeCombined)
+		}
+		a.StrtNmOrAdrLine1 = j.Street
+		a.BldgNbOrAdrLine2 = j.BuildingNumber
+	case AddressTypeCombined:
+		if j.Street != "" || j.BuildingNumber != "" {
+			return fmt.Errorf("address: street and buildingNumber are only permitted for type %s", AddressTypeStructured)
+		}
+		a.StrtNmOrAdrLine1 = j.AddressLine1
+		a.BldgNbOrAdrLine2 = j.AddressLine2
+	default:
+		return fmt.Errorf("address: invalid type %q, want %s or %s", j.Type, AddressTypeStructured, AddressTypeCombined)
+	}
+	return nil
+}
+
+type amountJSON struct {
+	Amount   string   `json:"amount,omitempty"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a QRCHCcyAmt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(amountJSON{
+		Amount:   a.Amt,
+		Currency: a.Ccy,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *QRCHCcyAmt) UnmarshalJSON(data []byte) error {
+	var j amountJSON
+	if err := decodeJSON(data, &j); err != nil {
+		return fmt.Errorf("amount: %w", err)
+	}
+	if err := j.Currency.Validate(); err != nil {
+		return fmt.Errorf("amount: %w", err)
+	}
+	if j.Amount != "" {
+		if _, err := ParseAmount(j.Amount); err != nil {
+			return fmt.Errorf("amount: %w", err)
+		}
+	}
+	*a = QRCHCcyAmt{
+		Amt: j.Amount,
+		Ccy: j.Currency,
+	}
+	return nil
+}
+
+type remittanceJSON struct {
+	ReferenceType   string `json:"referenceType"`
+	Reference       string `json:"reference,omitempty"`
+	Message         string `json:"message,omitempty"`
+	BillInformation string `json:"billInformation,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The trailer is a fixed value and
+// therefore not included.
+func (r QRCHRmtInf) MarshalJSON() ([]byte, error) {
+	return json.Marshal(remittanceJSON{
+		ReferenceType:   r.Tp,
+		Reference:       r.Ref,
+		Message:         r.AddInf.Ustrd,
+		BillInformation: r.AddInf.StrdBkgInf,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *QRCHRmtInf) UnmarshalJSON(data []byte) error {
+	var j remittanceJSON
+	if err := decodeJSON(data, &j); err != nil {
+		return fmt.Errorf("remittance: %w", err)
+	}
+	switch j.ReferenceType {
+	case ReferenceTypeQRR, ReferenceTypeSCOR, ReferenceTypeNON:
+	default:
+		return fmt.Errorf("remittance: invalid referenceType %q, want %s, %s or %s",
+			j.ReferenceType, ReferenceTypeQRR, ReferenceTypeSCOR, ReferenceTypeNON)
+	}
+	*r = QRCHRmtInf{
+		Tp:  j.ReferenceType,
+		Ref: j.Reference,
+		AddInf: QRCHRmtInfAddInf{
+			Ustrd:      j.Message,
+			StrdBkgInf: j.BillInformation,
+		},
+	}
+	return nil
+}
+
+type creditorJSON struct {
+	IBAN    string  `json:"iban"`
+	Address Address `json:"address"`
+}
+
+type qrchJSON struct {
+	SchemaVersion         int          `json:"schemaVersion"`
+	Creditor              creditorJSON `json:"creditor"`
+	UltimateCreditor      *Address     `json:"ultimateCreditor,omitempty"`
+	Amount                QRCHCcyAmt   `json:"amount"`
+	UltimateDebtor        *Address     `json:"ultimateDebtor,omitempty"`
+	Remittance            QRCHRmtInf   `json:"remittance"`
+	AlternativeProcedures []string     `json:"alternativeProcedures,omitempty"`
+}
+
+// optionalAddress returns nil for empty addresses, so that they are omitted.
+func optionalAddress(a Address) *Address {
+	if a.empty() {
+		return nil
+	}
+	return &a
+}
+
+// MarshalJSON implements json.Marshaler, see qrbill.schema.json for the
+// schema. The fixed values (Header, Trailer) are not included.
+func (q QRCH) MarshalJSON() ([]byte, error) {
+	return json.Marshal(qrchJSON{
+		SchemaVersion: JSONSchemaVersion,
+		Creditor: creditorJSON{
+			IBAN:    q.CdtrInf.IBAN,
+			Address: q.CdtrInf.Cdtr,
+		},
+		UltimateCreditor:      optionalAddress(q.UltmtCdtr),
+		Amount:                q.CcyAmt,
+		UltimateDebtor:        optionalAddress(q.UltmtDbtr),
+		Remittance:            q.RmtInf,
+		AlternativeProcedures: q.AltPmtInf.AltPmt,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Unknown fields and schema
+// versions are rejected, and the result is checked using ValidateStrict.
+func (q *QRCH) UnmarshalJSON(data []byte) error {
+	var j qrchJSON
+	if err := decodeJSON(data, &j); err != nil {
+		return err
+	}
+	if j.SchemaVersion != JSONSchemaVersion {
+		return fmt.Errorf("unsupported schemaVersion %d, want %d", j.SchemaVersion, JSONSchemaVersion)
+	}
+	result := QRCH{
+		CdtrInf: QRCHCdtrInf{
+			IBAN: j.Creditor.IBAN,
+			Cdtr: j.Creditor.Address,
+		},
+		CcyAmt:    j.Amount,
+		RmtInf:    j.Remittance,
+		AltPmtInf: QRCHAltPmtInf{AltPmt: j.AlternativeProcedures},
+	}
+	if j.UltimateCreditor != nil {
+		result.UltmtCdtr = *j.UltimateCreditor
+	}
+	if j.UltimateDebtor != nil {
+		result.UltmtDbtr = *j.UltimateDebtor
+	}
+	if err := result.ValidateStrict(); err != nil {
+		return err
+	}
+	*q = result
+	return nil
+}
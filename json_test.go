@@ -0,0 +1,251 @@
+package qrbill_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stapelberg/qrbill"
+)
+
+const testQRCHJSON = `{
+  "schemaVersion": 1,
+  "creditor": {
+    "iban": "CH0209000000870913543",
+    "address": {
+      "type": "S",
+      "name": "Legalize it",
+      "street": "Quellenstrasse",
+      "buildingNumber": "25",
+      "postalCode": "8005",
+      "town": "Zürich",
+      "country": "CH"
+    }
+  },
+  "amount": {
+    "amount": "50.00",
+    "currency": "CHF"
+  },
+  "ultimateDebtor": {
+    "type": "K",
+    "name": "Michael Stapelberg",
+    "addressLine1": "Stauffacherstr 42",
+    "addressLine2": "8004 Zürich",
+    "country": "CH"
+  },
+  "remittance": {
+    "referenceType": "NON",
+    "message": "Spende 420"
+  }
+}`
+
+// fullQRCH returns a QRCH which uses all JSON fields.
+func fullQRCH() *qrbill.QRCH {
+	qrch := testQRCH()
+	qrch.UltmtCdtr = qrch.CdtrInf.Cdtr
+	qrch.CdtrInf.IBAN = "CH4431999123000889012"
+	qrch.RmtInf.Tp = qrbill.ReferenceTypeQRR
+	qrch.RmtInf.Ref = "210000000003139471430009017"
+	qrch.RmtInf.AddInf.StrdBkgInf = "//S1/10/10201409/11/190512"
+	qrch.AltPmtInf.AltPmt = []string{"eBill/B/41010560425610173"}
+	return qrch
+}
+
+func TestMarshalJSON(t *testing.T) {
+	b, err := json.MarshalIndent(testQRCH(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), testQRCHJSON; got != want {
+		t.Errorf("MarshalJSON() = %s, want %s", got, want)
+	}
+
+	// QRCH values (e.g. struct fields) use the same representation:
+	b, err = json.MarshalIndent(*testQRCH(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), testQRCHJSON; got != want {
+		t.Errorf("MarshalJSON() of value = %s, want %s", got, want)
+	}
+
+	for _, want := range []*qrbill.QRCH{testQRCH(), fullQRCH()} {
+		b, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got qrbill.QRCH
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("UnmarshalJSON(MarshalJSON()) = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		replace [2]string
+	}{
+		{"unknown field", [2]string{`"message"`, `"msg"`}},
+		{"schema version", [2]string{`"schemaVersion": 1`, `"schemaVersion": 2`}},
+		{"missing schema version", [2]string{`"schemaVersion": 1,`, ``}},
+		{"address type", [2]string{`"type": "K"`, `"type": "X"`}},
+		{"address fields", [2]string{`"addressLine1"`, `"street"`}},
+		{"currency", [2]string{`"CHF"`, `"USD"`}},
+		{"amount", [2]string{`"50.00"`, `"50,00"`}},
+		{"reference type", [2]string{`"NON"`, `"ABC"`}},
+		{"validation", [2]string{`"CH0209000000870913543"`, `"CH0209000000870913544"`}},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			input := strings.Replace(testQRCHJSON, tt.replace[0], tt.replace[1], 1)
+			if input == testQRCHJSON {
+				t.Fatalf("replacement %q not found", tt.replace[0])
+			}
+			var qrch qrbill.QRCH
+			if err := json.Unmarshal([]byte(input), &qrch); err == nil {
+				t.Errorf("Unmarshal() unexpectedly succeeded")
+			}
+		})
+	}
+
+	var qrch qrbill.QRCH
+	input := strings.Replace(testQRCHJSON, `"CH0209000000870913543"`, `"CH4431999123000889012"`, 1)
+	var verr *qrbill.ValidationError
+	if err := json.Unmarshal([]byte(input), &qrch); !errors.As(err, &verr) {
+		t.Errorf("Unmarshal() = %v, want *ValidationError", err)
+	}
+}
+
+// schemaValidator implements the subset of JSON Schema used by
+// qrbill.schema.json and records which schema properties were used.
+type schemaValidator struct {
+	root map[string]interface{}
+	used map[string]bool
+}
+
+func (sv *schemaValidator) validate(path string, schema map[string]interface{}, value interface{}) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := strings.TrimPrefix(ref, "#/$defs/")
+		schema = sv.root["$defs"].(map[string]interface{})[def].(map[string]interface{})
+		path = "#/$defs/" + def
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if e == value {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v not in %v", path, value, enum)
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if schema["type"] != "object" {
+			return fmt.Errorf("%s: unexpected object", path)
+		}
+		props := schema["properties"].(map[string]interface{})
+		for key, val := range v {
+			prop, ok := props[key].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: property %q not in schema", path, key)
+			}
+			sv.used[path+"/"+key] = true
+			if err := sv.validate(path+"/"+key, prop, val); err != nil {
+				return err
+			}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, r := range required {
+			if _, ok := v[r.(string)]; !ok {
+				return fmt.Errorf("%s: required property %q missing", path, r)
+			}
+		}
+
+	case []interface{}:
+		if schema["type"] != "array" {
+			return fmt.Errorf("%s: unexpected array", path)
+		}
+		if max, ok := schema["maxItems"].(float64); ok && len(v) > int(max) {
+			return fmt.Errorf("%s: more than %v items", path, max)
+		}
+		for _, item := range v {
+			if err := sv.validate(path+"/items", schema["items"].(map[string]interface{}), item); err != nil {
+				return err
+			}
+		}
+
+	case string:
+		if t, ok := schema["type"]; ok && t != "string" {
+			return fmt.Errorf("%s: unexpected string", path)
+		}
+		if max, ok := schema["maxLength"].(float64); ok && utf8.RuneCountInString(v) > int(max) {
+			return fmt.Errorf("%s: %q longer than %v", path, v, max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			return fmt.Errorf("%s: %q does not match %s", path, v, pattern)
+		}
+	}
+	return nil
+}
+
+// properties returns the paths of all properties declared in schema.
+func properties(path string, schema map[string]interface{}) []string {
+	var result []string
+	props, _ := schema["properties"].(map[string]interface{})
+	for key, prop := range props {
+		result = append(result, path+"/"+key)
+		result = append(result, properties(path+"/"+key, prop.(map[string]interface{}))...)
+	}
+	return result
+}
+
+func TestJSONSchema(t *testing.T) {
+	b, err := os.ReadFile("qrbill.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	sv := &schemaValidator{
+		root: schema,
+		used: make(map[string]bool),
+	}
+	for _, qrch := range []*qrbill.QRCH{testQRCH(), fullQRCH()} {
+		b, err := json.Marshal(qrch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var value interface{}
+		if err := json.Unmarshal(b, &value); err != nil {
+			t.Fatal(err)
+		}
+		if err := sv.validate("#", schema, value); err != nil {
+			t.Errorf("MarshalJSON() output does not match schema: %v", err)
+		}
+	}
+
+	// Verify that the schema does not declare properties which the types do
+	// not produce:
+	declared := properties("#", schema)
+	for name, def := range schema["$defs"].(map[string]interface{}) {
+		declared = append(declared, properties("#/$defs/"+name, def.(map[string]interface{}))...)
+	}
+	for _, path := range declared {
+		if !sv.used[path] {
+			t.Errorf("schema property %s not produced by MarshalJSON", path)
+		}
+	}
+}
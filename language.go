@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import "fmt"
+
+// Language is a language in which the Implementation Guidelines define the
+// texts of the payment part and receipt.
+type Language string
+
+const (
+	LanguageGerman  Language = "de"
+	LanguageFrench  Language = "fr"
+	LanguageItalian Language = "it"
+	LanguageEnglish Language = "en"
+)
+
+// Languages lists all supported languages.
+var Languages = []Language{
+	LanguageGerman,
+	LanguageFrench,
+	LanguageItalian,
+	LanguageEnglish,
+}
+
+// Validate returns an error if l is not a supported language.
+func (l Language) Validate() error {
+	for _, supported := range Languages {
+		if l == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("language %q not supported, must be one of de, fr, it or en", string(l))
+}
+
+// slipLabels contains the texts of the payment slip.
+type slipLabels struct {
+	paymentPart           string
+	receipt               string
+	account               string
+	reference             string
+	additionalInformation string
+	payableBy             string
+	payableByNameAddress  string
+	currency              string
+	amount                string
+	acceptancePoint       string
+}
+
+// slipTexts contains the texts of the payment slip per language, as defined
+// in section 3.4 of the Implementation Guidelines.
+var slipTexts = map[Language]*slipLabels{
+	LanguageGerman: {
+		paymentPart:           "Zahlteil",
+		receipt:               "Empfangsschein",
+		account:               "Konto / Zahlbar an",
+		reference:             "Referenz",
+		additionalInformation: "Zusätzliche Informationen",
+		payableBy:             "Zahlbar durch",
+		payableByNameAddress:  "Zahlbar durch (Name/Adresse)",
+		currency:              "Währung",
+		amount:                "Betrag",
+		acceptancePoint:       "Annahmestelle",
+	},
+	LanguageFrench: {
+		paymentPart:           "Section paiement",
+		receipt:               "Récépissé",
+		account:               "Compte / Payable à",
+		reference:             "Référence",
+		additionalInformation: "Informations supplémentaires",
+		payableBy:             "Payable par",
+		payableByNameAddress:  "Payable par (nom/adresse)",
+		currency:              "Monnaie",
+		amount:                "Montant",
+		acceptancePoint:       "Point de dépôt",
+	},
+	LanguageItalian: {
+		paymentPart:           "Sezione pagamento",
+		receipt:               "Ricevuta",
+		account:               "Conto / Pagabile a",
+		reference:             "Riferimento",
+		additionalInformation: "Informazioni supplementari",
+		payableBy:             "Pagabile da",
+		payableByNameAddress:  "Pagabile da (nome/indirizzo)",
+		currency:              "Valuta",
+		amount:                "Importo",
+		acceptancePoint:       "Punto di accettazione",
+	},
+	LanguageEnglish: {
+		paymentPart:           "Payment part",
+		receipt:               "Receipt",
+		account:               "Account / Payable to",
+		reference:             "Reference",
+		additionalInformation: "Additional information",
+		payableBy:             "Payable by",
+		payableByNameAddress:  "Payable by (name/address)",
+		currency:              "Currency",
+		amount:                "Amount",
+		acceptancePoint:       "Acceptance point",
+	},
+}
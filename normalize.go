@@ -0,0 +1,226 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations contains replacements for characters which cannot be
+// transliterated by removing diacritics. They are only used if the character
+// is not permitted in the selected SpecVersion.
+var transliterations = map[rune]string{
+	'Æ': "AE", 'æ': "ae",
+	'Ð': "D", 'ð': "d",
+	'Đ': "D", 'đ': "d",
+	'Ħ': "H", 'ħ': "h",
+	'ı': "i",
+	'Ł': "L", 'ł': "l",
+	'Œ': "OE", 'œ': "oe",
+	'Ø': "O", 'ø': "o",
+	'Þ': "TH", 'þ': "th",
+	'ẞ': "SS",
+
+	'“': `"`, '”': `"`, '„': `"`, '«': `"`, '»': `"`,
+	'‘': "'", '’': "'", '‚': "'", '‹': "'", '›': "'",
+	'‐': "-", '‑': "-", '–': "-", '—': "-", '−': "-",
+	'…': "...",
+	'€': "EUR",
+
+	// Whitespace other than space would break the payload lines:
+	'\t': " ", '\n': " ", '\r': " ", '\u00a0': " ",
+}
+
+// transliterate returns the closest replacement for r which is permitted in
+// v, or an empty string if there is none.
+func (v SpecVersion) transliterate(r rune) string {
+	if t, ok := transliterations[r]; ok && v.permittedChars(t) {
+		return t
+	}
+	// Remove diacritics, e.g. ą → a:
+	var b strings.Builder
+	for _, d := range norm.NFD.String(string(r)) {
+		if !unicode.Is(unicode.Mn, d) {
+			b.WriteRune(d)
+		}
+	}
+	if t := b.String(); t != string(r) && v.permittedChars(t) {
+		return t
+	}
+	return ""
+}
+
+// normalizeChars returns s with all characters which are not permitted in v
+// transliterated to the closest permitted characters, e.g. Łódź → Lódz.
+// Characters without a permitted replacement are removed.
+func (v SpecVersion) normalizeChars(s string) string {
+	s = norm.NFC.String(s)
+	if v.permittedChars(s) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if c := string(r); v.permittedChars(c) {
+			b.WriteString(c)
+		} else {
+			b.WriteString(v.transliterate(r))
+		}
+	}
+	return b.String()
+}
+
+// ChangeKind classifies a Change.
+type ChangeKind int
+
+const (
+	// ChangeTruncated means the value was shortened to the maximum length.
+	ChangeTruncated ChangeKind = iota + 1
+
+	// ChangeStripped means characters were removed from the value, e.g.
+	// spaces in the IBAN or characters without a permitted replacement.
+	ChangeStripped
+
+	// ChangeReformatted means characters were replaced, e.g. transliterated
+	// characters, upper-cased country codes or amounts with added decimal
+	// places.
+	ChangeReformatted
+)
+
+// String implements fmt.Stringer.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeTruncated:
+		return "truncated"
+	case ChangeStripped:
+		return "stripped"
+	case ChangeReformatted:
+		return "reformatted"
+	}
+	return "unknown"
+}
+
+// Change describes a field which was modified during normalization.
+type Change struct {
+	Field  string     // Field path, e.g. CdtrInf.Cdtr.Name
+	Kind   ChangeKind // Kind of modification
+	Before string     // Value before normalization
+	After  string     // Value after normalization
+}
+
+// String implements fmt.Stringer.
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s: %q → %q", c.Field, c.Kind, c.Before, c.After)
+}
+
+// isSubsequence reports whether after can be obtained by removing characters
+// from before.
+func isSubsequence(after, before string) bool {
+	rest := []rune(after)
+	for _, r := range before {
+		if len(rest) > 0 && rest[0] == r {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
+
+// field is the path and value of a QRCH field.
+type field struct {
+	name, value string
+}
+
+func (a Address) fields(prefix string) []field {
+	return []field{
+		{prefix + "AdrTp", string(a.AdrTp)},
+		{prefix + "Name", a.Name},
+		{prefix + "StrtNmOrAdrLine1", a.StrtNmOrAdrLine1},
+		{prefix + "BldgNbOrAdrLine2", a.BldgNbOrAdrLine2},
+		{prefix + "PstCd", a.PstCd},
+		{prefix + "TwnNm", a.TwnNm},
+		{prefix + "Ctry", a.Ctry},
+	}
+}
+
+// fields returns all fields of q which are not fixed values, in payload
+// order.
+func (q *QRCH) fields() []field {
+	var fs []field
+	fs = append(fs, field{"CdtrInf.IBAN", q.CdtrInf.IBAN})
+	fs = append(fs, q.CdtrInf.Cdtr.fields("CdtrInf.Cdtr.")...)
+	fs = append(fs, q.UltmtCdtr.fields("UltmtCdtr.")...)
+	fs = append(fs,
+		field{"CcyAmt.Amt", q.CcyAmt.Amt},
+		field{"CcyAmt.Ccy", string(q.CcyAmt.Ccy)})
+	fs = append(fs, q.UltmtDbtr.fields("UltmtDbtr.")...)
+	fs = append(fs,
+		field{"RmtInf.Tp", q.RmtInf.Tp},
+		field{"RmtInf.Ref", q.RmtInf.Ref},
+		field{"RmtInf.AddInf.Ustrd", q.RmtInf.AddInf.Ustrd},
+		field{"RmtInf.AddInf.StrdBkgInf", q.RmtInf.AddInf.StrdBkgInf})
+	for idx, v := range q.AltPmtInf.AltPmt {
+		fs = append(fs, field{fmt.Sprintf("AltPmtInf.AltPmt[%d]", idx), v})
+	}
+	return fs
+}
+
+// value returns the value of the field at idx, or an empty string if fs is
+// shorter (e.g. if alternative procedures were removed).
+func value(fs []field, idx int) string {
+	if idx < len(fs) {
+		return fs[idx].value
+	}
+	return ""
+}
+
+// changes returns the fields which differ between before and after. chars is
+// the intermediate result with only the characters normalized, which is used
+// to determine the kind of change.
+func changes(before, chars, after *QRCH) []Change {
+	bf, cf, af := before.fields(), chars.fields(), after.fields()
+	var result []Change
+	for idx, f := range bf {
+		b, c, a := f.value, value(cf, idx), value(af, idx)
+		if a == b {
+			continue
+		}
+		kind := ChangeReformatted
+		switch {
+		case c != a && strings.HasPrefix(c, a):
+			kind = ChangeTruncated
+		case c == a && isSubsequence(a, b):
+			kind = ChangeStripped
+		}
+		result = append(result, Change{
+			Field:  f.name,
+			Kind:   kind,
+			Before: b,
+			After:  a,
+		})
+	}
+	return result
+}
+
+// Normalize is like Validate, but additionally returns all changes made to
+// the fields of q (apart from filling in fixed values), e.g. transliterated
+// characters or truncated values.
+func (q *QRCH) Normalize(opts ...Option) (*QRCH, []Change) {
+	chars := q.withPermittedChars(newOptions(opts))
+	normalized := q.Validate(opts...)
+	return normalized, changes(q, chars, normalized)
+}
@@ -0,0 +1,121 @@
+package qrbill_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestNormalizeCharacters(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		version qrbill.SpecVersion
+		want    string
+	}{
+		{input: "Łódź", version: qrbill.SpecVersion21, want: "Lódz"}, // ó is permitted
+		{input: "Øystein", version: qrbill.SpecVersion21, want: "Oystein"},
+		{input: "“Rechnung” ‘42’", version: qrbill.SpecVersion21, want: `"Rechnung" '42'`},
+		{input: "Ærø – Æbeløe", version: qrbill.SpecVersion21, want: "AEro - AEbeloe"},
+		{input: "Zürich", version: qrbill.SpecVersion21, want: "Zürich"},
+		{input: "Zu\u0308rich", version: qrbill.SpecVersion21, want: "Zürich"}, // decomposed
+		{input: "Ștefan", version: qrbill.SpecVersion21, want: "Stefan"},
+		{input: "Zeile 1\nZeile 2", version: qrbill.SpecVersion21, want: "Zeile 1 Zeile 2"},
+		{input: "10 €", version: qrbill.SpecVersion21, want: "10 EUR"},
+		{input: "Hi ❤", version: qrbill.SpecVersion21, want: "Hi "},
+
+		// Version 2.3 permits the extended character set:
+		{input: "Łódź", version: qrbill.SpecVersion23, want: "Łódź"},
+		{input: "Øystein", version: qrbill.SpecVersion23, want: "Øystein"},
+		{input: "10 €", version: qrbill.SpecVersion23, want: "10 €"},
+		{input: "“Rechnung”", version: qrbill.SpecVersion23, want: `"Rechnung"`},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			qrch := testQRCH()
+			qrch.RmtInf.AddInf.Ustrd = tt.input
+			qrch.CdtrInf.Cdtr.Name = tt.input
+			normalized := qrch.Validate(qrbill.WithSpecVersion(tt.version))
+			if got := normalized.RmtInf.AddInf.Ustrd; got != tt.want {
+				t.Errorf("RmtInf.AddInf.Ustrd = %q, want %q", got, tt.want)
+			}
+			if got := normalized.CdtrInf.Cdtr.Name; got != tt.want {
+				t.Errorf("CdtrInf.Cdtr.Name = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	qrch := testQRCH()
+	qrch.CdtrInf.IBAN = "CH02 0900 0000 8709 1354 3"
+	qrch.CdtrInf.Cdtr.TwnNm = "Łódź"
+	qrch.CcyAmt.Amt = "50"
+	qrch.RmtInf.AddInf.Ustrd = "„Spende“"
+
+	normalized, changes := qrch.Normalize()
+	want := []qrbill.Change{
+		{Field: "CdtrInf.IBAN", Kind: qrbill.ChangeStripped, Before: "CH02 0900 0000 8709 1354 3", After: "CH0209000000870913543"},
+		{Field: "CdtrInf.Cdtr.TwnNm", Kind: qrbill.ChangeReformatted, Before: "Łódź", After: "Lódz"},
+		{Field: "CcyAmt.Amt", Kind: qrbill.ChangeReformatted, Before: "50", After: "50.00"},
+		{Field: "RmtInf.AddInf.Ustrd", Kind: qrbill.ChangeReformatted, Before: "„Spende“", After: `"Spende"`},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Normalize() changes = %v, want %v", changes, want)
+	}
+	if err := normalized.ValidateStrict(); err != nil {
+		t.Errorf("ValidateStrict() = %v", err)
+	}
+
+	if _, changes := normalized.Normalize(); len(changes) > 0 {
+		t.Errorf("Normalize() of normalized QRCH = %v, want no changes", changes)
+	}
+}
+
+func TestBillChanges(t *testing.T) {
+	qrch := testQRCH()
+	qrch.CdtrInf.Cdtr.Name = strings.Repeat("Müller-Lüdenscheidt AG ", 4)
+	qrch.RmtInf.AddInf.Ustrd = "Spende 420 ❤"
+
+	bill, err := qrch.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []qrbill.Change{
+		{
+			Field:  "CdtrInf.Cdtr.Name",
+			Kind:   qrbill.ChangeTruncated,
+			Before: qrch.CdtrInf.Cdtr.Name,
+			After:  "Müller-Lüdenscheidt AG Müller-Lüdenscheidt AG Müller-Lüdenscheidt AG M",
+		},
+		{
+			Field:  "RmtInf.AddInf.Ustrd",
+			Kind:   qrbill.ChangeStripped,
+			Before: "Spende 420 ❤",
+			After:  "Spende 420 ",
+		},
+	}
+	if got := bill.Changes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %v, want %v", got, want)
+	}
+
+	normalized := bill.QRCH()
+	if got, want := normalized.CdtrInf.Cdtr.Name, want[0].After; got != want {
+		t.Errorf("QRCH().CdtrInf.Cdtr.Name = %q, want %q", got, want)
+	}
+	if got, want := normalized.Header.QRType, qrbill.QRType; got != want {
+		t.Errorf("QRCH().Header.QRType = %q, want %q", got, want)
+	}
+
+	// The normalized QRCH encodes to the same bill:
+	again, err := normalized.Encode(qrbill.WithStrictValidation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := again.EncodeToString(), bill.EncodeToString(); got != want {
+		t.Errorf("EncodeToString() = %q, want %q", got, want)
+	}
+	if got := again.Changes(); len(got) > 0 {
+		t.Errorf("Changes() = %v, want none", got)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+// notificationAmount is the amount of notifications, e.g. for eBill or LSV+,
+// which must not be paid.
+const notificationAmount = "0.00"
+
+// notificationMessages contains the unstructured message of notifications
+// per language, as defined by the Implementation Guidelines.
+var notificationMessages = map[Language]string{
+	LanguageGerman:  "NICHT ZUR ZAHLUNG VERWENDEN",
+	LanguageFrench:  "NE PAS UTILISER POUR LE PAIEMENT",
+	LanguageItalian: "NON UTILIZZARE PER IL PAGAMENTO",
+	LanguageEnglish: "DO NOT USE FOR PAYMENT",
+}
+
+// SetNotification turns q into a notification, which is used e.g. for eBill
+// or LSV+ to inform the debtor about a debit which must not be paid using
+// the QR-bill: the amount is set to 0.00 and the unstructured message to
+// “NICHT ZUR ZAHLUNG VERWENDEN” (or its translation into lang).
+func (q *QRCH) SetNotification(lang Language) error {
+	if err := lang.Validate(); err != nil {
+		return err
+	}
+	q.CcyAmt.Amt = notificationAmount
+	q.RmtInf.AddInf.Ustrd = notificationMessages[lang]
+	return nil
+}
+
+// IsNotification reports whether q is a notification, see SetNotification.
+func (q *QRCH) IsNotification() bool {
+	if q.CcyAmt.Amt != notificationAmount {
+		return false
+	}
+	for _, msg := range notificationMessages {
+		if q.RmtInf.AddInf.Ustrd == msg {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package qrbill_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestNotification(t *testing.T) {
+	for _, tt := range []struct {
+		lang qrbill.Language
+		want string
+	}{
+		{qrbill.LanguageGerman, "NICHT ZUR ZAHLUNG VERWENDEN"},
+		{qrbill.LanguageFrench, "NE PAS UTILISER POUR LE PAIEMENT"},
+		{qrbill.LanguageItalian, "NON UTILIZZARE PER IL PAGAMENTO"},
+		{qrbill.LanguageEnglish, "DO NOT USE FOR PAYMENT"},
+	} {
+		t.Run(string(tt.lang), func(t *testing.T) {
+			qrch := testQRCH()
+			if qrch.IsNotification() {
+				t.Fatalf("IsNotification() = true before SetNotification")
+			}
+			if err := qrch.SetNotification(tt.lang); err != nil {
+				t.Fatal(err)
+			}
+			if !qrch.IsNotification() {
+				t.Errorf("IsNotification() = false after SetNotification")
+			}
+			bill, err := qrch.Encode(qrbill.WithStrictValidation())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bill.IsNotification() {
+				t.Errorf("Bill.IsNotification() = false")
+			}
+			lines := strings.Split(bill.EncodeToString(), "\n")
+			if got, want := lines[18], "0.00"; got != want {
+				t.Errorf("payload amount = %q, want %q", got, want)
+			}
+			if got := lines[29]; got != tt.want {
+				t.Errorf("payload message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	qrch := testQRCH()
+	if err := qrch.SetNotification("rm"); err == nil {
+		t.Errorf("SetNotification(rm) unexpectedly succeeded")
+	}
+
+	// 0.00 is rejected for bills which are not notifications:
+	qrch.CcyAmt.Amt = "0.00"
+	var verr *qrbill.ValidationError
+	if err := qrch.ValidateStrict(); !errors.As(err, &verr) {
+		t.Fatalf("ValidateStrict() = %v, want *ValidationError", err)
+	}
+	if got, want := verr.Violations[0].Field, "CcyAmt.Amt"; got != want {
+		t.Errorf("violation field = %q, want %q", got, want)
+	}
+
+	bill, err := qrbill.NewBuilder().
+		Creditor(qrch.CdtrInf.IBAN, qrch.CdtrInf.Cdtr).
+		Notification(qrbill.LanguageFrench).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bill.IsNotification() {
+		t.Errorf("Bill.IsNotification() = false for Builder.Notification")
+	}
+}
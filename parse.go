@@ -0,0 +1,211 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Errors which Parse wraps in a *ParseError. Use errors.Is to check for them.
+var (
+	ErrInvalidHeader      = errors.New("not a Swiss QR code: QR type must be SPC")
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	ErrUnsupportedCoding  = errors.New("unsupported coding type")
+	ErrInvalidEncoding    = errors.New("invalid UTF-8 encoding")
+	ErrInvalidLineCount   = errors.New("invalid number of lines")
+	ErrMissingTrailer     = errors.New("missing trailer " + Trailer)
+)
+
+// ParseError describes why a Swiss QR code payload could not be parsed.
+type ParseError struct {
+	Line  int    // Line number (starting at 1), or 0 if not specific to a line
+	Field string // Field path, e.g. Header.Version
+	Value string // Offending value
+	Err   error  // One of the Err* variables
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("qrbill: %v", e.Err)
+	}
+	return fmt.Sprintf("qrbill: line %d (%s): %v (got %q)", e.Line, e.Field, e.Err, e.Value)
+}
+
+// Unwrap returns the underlying Err* variable.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// payloadFields lists the field paths of all lines of a Swiss QR code payload,
+// in order.
+var payloadFields = []string{
+	"Header.QRType",
+	"Header.Version",
+	"Header.Coding",
+
+	"CdtrInf.IBAN",
+
+	"CdtrInf.Cdtr.AdrTp",
+	"CdtrInf.Cdtr.Name",
+	"CdtrInf.Cdtr.StrtNmOrAdrLine1",
+	"CdtrInf.Cdtr.BldgNbOrAdrLine2",
+	"CdtrInf.Cdtr.PstCd",
+	"CdtrInf.Cdtr.TwnNm",
+	"CdtrInf.Cdtr.Ctry",
+
+	"UltmtCdtr.AdrTp",
+	"UltmtCdtr.Name",
+	"UltmtCdtr.StrtNmOrAdrLine1",
+	"UltmtCdtr.BldgNbOrAdrLine2",
+	"UltmtCdtr.PstCd",
+	"UltmtCdtr.TwnNm",
+	"UltmtCdtr.Ctry",
+
+	"CcyAmt.Amt",
+	"CcyAmt.Ccy",
+
+	"UltmtDbtr.AdrTp",
+	"UltmtDbtr.Name",
+	"UltmtDbtr.StrtNmOrAdrLine1",
+	"UltmtDbtr.BldgNbOrAdrLine2",
+	"UltmtDbtr.PstCd",
+	"UltmtDbtr.TwnNm",
+	"UltmtDbtr.Ctry",
+
+	"RmtInf.Tp",
+	"RmtInf.Ref",
+	"RmtInf.AddInf.Ustrd",
+	"RmtInf.AddInf.Trailer",
+
+	// Optional elements:
+	"RmtInf.AddInf.StrdBkgInf",
+	"AltPmtInf.AltPmt[0]",
+	"AltPmtInf.AltPmt[1]",
+}
+
+// trailerLines is the number of lines up to and including the trailer, which
+// are mandatory.
+const trailerLines = 31
+
+// parseAddress parses the 7 lines of a (combined or structured) address.
+func parseAddress(lines []string) Address {
+	return Address{
+		AdrTp:            AddressType(lines[0]),
+		Name:             lines[1],
+		StrtNmOrAdrLine1: lines[2],
+		BldgNbOrAdrLine2: lines[3],
+		PstCd:            lines[4],
+		TwnNm:            lines[5],
+		Ctry:             lines[6],
+	}
+}
+
+// Parse parses the payload of a Swiss QR code (as returned by
+// Bill.EncodeToString) into a QRCH. Lines may be separated by LF or CR LF.
+//
+// Parse only checks the structure of the payload. Use QRCH.ValidateStrict to
+// check the contents.
+func Parse(payload string) (*QRCH, error) {
+	payload = strings.ReplaceAll(payload, "\r\n", "\n")
+	// The last element may or may not be terminated by a line break:
+	payload = strings.TrimSuffix(payload, "\n")
+	lines := strings.Split(payload, "\n")
+
+	lineErr := func(idx int, err error) error {
+		return &ParseError{
+			Line:  idx + 1,
+			Field: payloadFields[idx],
+			Value: lines[idx],
+			Err:   err,
+		}
+	}
+
+	for idx, line := range lines {
+		if !utf8.ValidString(line) {
+			field := ""
+			if idx < len(payloadFields) {
+				field = payloadFields[idx]
+			}
+			return nil, &ParseError{
+				Line:  idx + 1,
+				Field: field,
+				Value: line,
+				Err:   ErrInvalidEncoding,
+			}
+		}
+	}
+
+	if lines[0] != QRType {
+		return nil, lineErr(0, ErrInvalidHeader)
+	}
+	if len(lines) < 3 {
+		return nil, &ParseError{Err: fmt.Errorf("%w: got %d, want %d to %d", ErrInvalidLineCount, len(lines), trailerLines, len(payloadFields))}
+	}
+	// All versions with the same main version are compatible:
+	if v := lines[1]; len(v) != 4 || !isDigits(v) || v[:2] != Version[:2] {
+		return nil, lineErr(1, ErrUnsupportedVersion)
+	}
+	if lines[2] != CodingType {
+		return nil, lineErr(2, ErrUnsupportedCoding)
+	}
+
+	if len(lines) < trailerLines || len(lines) > len(payloadFields) {
+		return nil, &ParseError{Err: fmt.Errorf("%w: got %d, want %d to %d", ErrInvalidLineCount, len(lines), trailerLines, len(payloadFields))}
+	}
+	if idx := trailerLines - 1; lines[idx] != Trailer {
+		return nil, lineErr(idx, ErrMissingTrailer)
+	}
+
+	var strdBkgInf string
+	if len(lines) > trailerLines {
+		strdBkgInf = lines[trailerLines]
+	}
+	var altPmt []string
+	if len(lines) > trailerLines+1 {
+		altPmt = lines[trailerLines+1:]
+	}
+
+	return &QRCH{
+		Header: QRCHHeader{
+			QRType:  lines[0],
+			Version: lines[1],
+			Coding:  lines[2],
+		},
+		CdtrInf: QRCHCdtrInf{
+			IBAN: lines[3],
+			Cdtr: parseAddress(lines[4:11]),
+		},
+		UltmtCdtr: parseAddress(lines[11:18]),
+		CcyAmt: QRCHCcyAmt{
+			Amt: lines[18],
+			Ccy: Currency(lines[19]),
+		},
+		UltmtDbtr: parseAddress(lines[20:27]),
+		RmtInf: QRCHRmtInf{
+			Tp:  lines[27],
+			Ref: lines[28],
+			AddInf: QRCHRmtInfAddInf{
+				Ustrd:      lines[29],
+				Trailer:    lines[30],
+				StrdBkgInf: strdBkgInf,
+			},
+		},
+		AltPmtInf: QRCHAltPmtInf{
+			AltPmt: altPmt,
+		},
+	}, nil
+}
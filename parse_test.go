@@ -0,0 +1,202 @@
+package qrbill_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+// testQRCH returns a QRCH with all fields filled in.
+func testQRCH() *qrbill.QRCH {
+	return &qrbill.QRCH{
+		CdtrInf: qrbill.QRCHCdtrInf{
+			IBAN: "CH0209000000870913543",
+			Cdtr: qrbill.Address{
+				AdrTp:            qrbill.AddressTypeStructured,
+				Name:             "Legalize it",
+				StrtNmOrAdrLine1: "Quellenstrasse",
+				BldgNbOrAdrLine2: "25",
+				PstCd:            "8005",
+				TwnNm:            "Zürich",
+				Ctry:             "CH",
+			},
+		},
+		CcyAmt: qrbill.QRCHCcyAmt{
+			Amt: "50.00",
+			Ccy: "CHF",
+		},
+		UltmtDbtr: qrbill.Address{
+			AdrTp:            qrbill.AddressTypeCombined,
+			Name:             "Michael Stapelberg",
+			StrtNmOrAdrLine1: "Stauffacherstr 42",
+			BldgNbOrAdrLine2: "8004 Zürich",
+			Ctry:             "CH",
+		},
+		RmtInf: qrbill.QRCHRmtInf{
+			Tp:  "NON",
+			Ref: "",
+			AddInf: qrbill.QRCHRmtInfAddInf{
+				Ustrd: "Spende 420",
+			},
+		},
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	qrch := testQRCH()
+	bill, err := qrch.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := bill.EncodeToString()
+
+	for _, tt := range []struct {
+		name    string
+		payload string
+	}{
+		{name: "LF", payload: payload},
+		{name: "CRLF", payload: strings.ReplaceAll(payload, "\n", "\r\n")},
+		{name: "NoFinalLineBreak", payload: strings.TrimSuffix(payload, "\n")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := qrbill.Parse(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := qrch.Validate(); !reflect.DeepEqual(parsed, want) {
+				t.Errorf("Parse() = %+v, want %+v", parsed, want)
+			}
+			reencoded, err := parsed.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := reencoded.EncodeToString(), payload; got != want {
+				t.Errorf("Encode(Parse()) = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bill, err := testQRCH().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(bill.EncodeToString(), "\n"), "\n")
+	modified := func(idx int, value string) string {
+		l := append([]string{}, lines...)
+		l[idx] = value
+		return strings.Join(l, "\n")
+	}
+
+	for _, tt := range []struct {
+		name     string
+		payload  string
+		wantErr  error
+		wantLine int
+	}{
+		{
+			name:     "Empty",
+			payload:  "",
+			wantErr:  qrbill.ErrInvalidHeader,
+			wantLine: 1,
+		},
+
+		{
+			name:     "WrongHeader",
+			payload:  modified(0, "BCD"),
+			wantErr:  qrbill.ErrInvalidHeader,
+			wantLine: 1,
+		},
+
+		{
+			name:     "UnsupportedVersion",
+			payload:  modified(1, "0100"),
+			wantErr:  qrbill.ErrUnsupportedVersion,
+			wantLine: 2,
+		},
+
+		{
+			name:     "UnsupportedCoding",
+			payload:  modified(2, "2"),
+			wantErr:  qrbill.ErrUnsupportedCoding,
+			wantLine: 3,
+		},
+
+		{
+			name:     "InvalidEncoding",
+			payload:  modified(5, "Legalize \xff"),
+			wantErr:  qrbill.ErrInvalidEncoding,
+			wantLine: 6,
+		},
+
+		{
+			name:    "TooFewLines",
+			payload: strings.Join(append(append([]string{}, lines[:20]...), lines[21:]...), "\n"),
+			wantErr: qrbill.ErrInvalidLineCount,
+		},
+
+		{
+			name:    "TooManyLines",
+			payload: strings.Join(append(append([]string{}, lines...), "EPD", "EPD", "EPD", "EPD"), "\n"),
+			wantErr: qrbill.ErrInvalidLineCount,
+		},
+
+		{
+			name:     "MissingTrailer",
+			payload:  modified(30, "END"),
+			wantErr:  qrbill.ErrMissingTrailer,
+			wantLine: 31,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := qrbill.Parse(tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() = %v, want %v", err, tt.wantErr)
+			}
+			var perr *qrbill.ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse() = %v, want *ParseError", err)
+			}
+			if got, want := perr.Line, tt.wantLine; got != want {
+				t.Errorf("ParseError.Line = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestParseAltPmtInf(t *testing.T) {
+	qrch := testQRCH()
+	qrch.AltPmtInf.AltPmt = []string{
+		"eBill/B/41010560425610173",
+		"//foo/bar",
+	}
+	bill, err := qrch.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := bill.EncodeToString()
+	if want := "EPD\n\neBill/B/41010560425610173\n//foo/bar\n"; !strings.HasSuffix(payload, want) {
+		t.Errorf("EncodeToString() = %q, want suffix %q", payload, want)
+	}
+
+	parsed, err := qrbill.Parse(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := qrch.Validate(); !reflect.DeepEqual(parsed, want) {
+		t.Errorf("Parse() = %+v, want %+v", parsed, want)
+	}
+
+	qrch.AltPmtInf.AltPmt = append(qrch.AltPmtInf.AltPmt, "third")
+	var verr *qrbill.ValidationError
+	if _, err := qrch.Encode(qrbill.WithStrictValidation()); !errors.As(err, &verr) {
+		t.Errorf("Encode(WithStrictValidation()) = %v, want *ValidationError", err)
+	}
+	if got, want := len(qrch.Validate().AltPmtInf.AltPmt), 2; got != want {
+		t.Errorf("len(Validate().AltPmtInf.AltPmt) = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,614 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/encoder"
+)
+
+// The payment slip layout follows section 3.4 (“Layout of the QR-bill”) of
+// the Implementation Guidelines. All positions and dimensions are in mm,
+// relative to the top left corner of the payment slip.
+const (
+	slipWidth    = 210
+	slipHeight   = 105
+	slipMargin   = 5
+	receiptWidth = 62
+
+	// slipQRCodeSize is the edge length of the Swiss QR Code on the payment
+	// part, without quiet zone.
+	slipQRCodeSize = 46
+
+	// amountY is the top of the amount sections of the receipt and the
+	// payment part. The information section of the receipt ends there.
+	amountY = 68
+
+	// titleSize is the font size (in pt) of “Receipt” and “Payment part”.
+	titleSize = 11
+
+	// separatorWidth is the width (in pt) of the separation lines and of the
+	// corner marks of blank fields.
+	separatorWidth = 0.75
+
+	// separatorDash is the length of the dashes (and gaps) of the separation
+	// lines.
+	separatorDash = 1
+)
+
+// mm converts pt (1/72 inch) to mm.
+func mm(pt float64) float64 {
+	return pt * 25.4 / 72
+}
+
+type fontStyle int
+
+const (
+	fontRegular fontStyle = iota
+	fontBold
+)
+
+type point struct {
+	x, y float64
+}
+
+type rect struct {
+	x, y, w, h float64
+}
+
+// canvas is implemented by the renderers of the payment slip. Positions are
+// in mm, relative to the top left corner of the payment slip. Font sizes and
+// line widths are in pt.
+type canvas interface {
+	// text draws s with its baseline starting at (x, y).
+	text(x, y float64, style fontStyle, size float64, s string)
+
+	// textWidth returns the width of s in mm.
+	textWidth(style fontStyle, size float64, s string) float64
+
+	// stroke draws a black line through points, dashed with dashes and gaps
+	// of length dash, or solid if dash is 0.
+	stroke(width, dash float64, points ...point)
+
+	// circle draws a black circle line around (x, y).
+	circle(x, y, r, width float64)
+
+	// fill fills rects with gray (0 is black, 1 is white).
+	fill(gray float64, rects ...rect)
+}
+
+// partStyle contains the font sizes and spacing (in pt) of the receipt or the
+// payment part.
+type partStyle struct {
+	heading    float64
+	value      float64
+	lineHeight float64
+	gap        float64 // between paragraphs
+
+	// maxAdditionalLines is the maximum number of lines of additional
+	// information, which is not printed on the receipt.
+	maxAdditionalLines int
+}
+
+// baseline returns the baseline of a line (heading or value) starting at y.
+func (s *partStyle) baseline(y float64) float64 {
+	return y + mm(0.8*s.lineHeight)
+}
+
+var (
+	receiptStyle = partStyle{
+		heading:    6,
+		value:      8,
+		lineHeight: 9,
+		gap:        6,
+	}
+
+	paymentPartStyle = partStyle{
+		heading:            8,
+		value:              10,
+		lineHeight:         11,
+		gap:                6,
+		maxAdditionalLines: 4,
+	}
+)
+
+// formatIBAN returns iban in groups of 4 characters.
+func formatIBAN(iban string) string {
+	return groupFromLeft(stripSpaces(iban), 4)
+}
+
+// formatReference returns the reference in groups of 5 digits from the right
+// (QR reference) or of 4 characters from the left (Creditor Reference).
+func formatReference(r QRCHRmtInf) string {
+	ref := stripSpaces(r.Ref)
+	switch r.Tp {
+	case ReferenceTypeQRR:
+		var groups []string
+		for len(ref) > 5 {
+			groups = append([]string{ref[len(ref)-5:]}, groups...)
+			ref = ref[:len(ref)-5]
+		}
+		return strings.Join(append([]string{ref}, groups...), " ")
+	case ReferenceTypeSCOR:
+		return groupFromLeft(ref, 4)
+	}
+	return ""
+}
+
+func groupFromLeft(s string, n int) string {
+	var groups []string
+	for len(s) > n {
+		groups = append(groups, s[:n])
+		s = s[n:]
+	}
+	return strings.Join(append(groups, s), " ")
+}
+
+// formatAmount returns amt with a space as thousands separator, e.g.
+// “1 949.75”, or the empty string if amt is not a valid amount.
+func formatAmount(amt string) string {
+	a, err := ParseAmount(amt)
+	if err != nil {
+		return ""
+	}
+	s := a.String()
+	units, cents := s[:len(s)-3], s[len(s)-3:]
+	var groups []string
+	for len(units) > 3 {
+		groups = append([]string{units[len(units)-3:]}, groups...)
+		units = units[:len(units)-3]
+	}
+	return strings.Join(append([]string{units}, groups...), " ") + cents
+}
+
+// addressLines returns the lines of a as printed on the payment slip. The
+// postal code of structured addresses outside of Switzerland and
+// Liechtenstein is prefixed with the country code.
+func addressLines(a Address) []string {
+	lines := []string{a.Name, a.StrtNmOrAdrLine1, a.BldgNbOrAdrLine2}
+	if a.AdrTp != AddressTypeCombined {
+		town := a.PstCd + " " + a.TwnNm
+		if a.Ctry != "CH" && a.Ctry != "LI" {
+			town = a.Ctry + "-" + town
+		}
+		lines = []string{
+			a.Name,
+			strings.TrimSpace(a.StrtNmOrAdrLine1 + " " + a.BldgNbOrAdrLine2),
+			town,
+		}
+	}
+	result := lines[:0]
+	for _, line := range lines {
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// SlipOption configures the rendering of the payment slip.
+type SlipOption func(*slipOptions)
+
+type slipOptions struct {
+	lang Language
+
+	// regularFont and boldFont are TrueType font files to embed in PDF
+	// documents instead of using Helvetica, see WithPDFFonts.
+	regularFont, boldFont []byte
+
+	// perforated leaves out the separation lines, see WithPerforatedPaper.
+	perforated bool
+
+	// separateAbove draws a separation line above the payment slip, for
+	// printing it at the bottom of a page.
+	separateAbove bool
+}
+
+func newSlipOptions(opts []SlipOption) (*slipOptions, error) {
+	o := &slipOptions{lang: LanguageEnglish}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.lang.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// WithLanguage selects the language of the texts of the payment slip, e.g.
+// the language of the debtor. The default is English.
+func WithLanguage(lang Language) SlipOption {
+	return func(o *slipOptions) {
+		o.lang = lang
+	}
+}
+
+// WithPDFFonts embeds the specified TrueType font files (.ttf) in PDF
+// documents, e.g. Liberation Sans or Arial as permitted by the Implementation
+// Guidelines, instead of using the Helvetica fonts provided by the PDF viewer.
+// This is required for printing characters outside of Windows code page 1252,
+// e.g. the extended character set of version 2.3 (ș, ő, …).
+//
+// The font files are embedded as a whole, which increases the size of the
+// documents accordingly. SVG documents are not affected.
+func WithPDFFonts(regular, bold []byte) SlipOption {
+	return func(o *slipOptions) {
+		o.regularFont = regular
+		o.boldFont = bold
+	}
+}
+
+// WithPerforatedPaper leaves out the separation lines and scissors symbols,
+// for printing on paper which is perforated between the receipt and the
+// payment part (and above the payment slip), where the perforation serves as
+// the separation.
+func WithPerforatedPaper() SlipOption {
+	return func(o *slipOptions) {
+		o.perforated = true
+	}
+}
+
+// slipLayout draws the payment slip of a bill onto a canvas.
+type slipLayout struct {
+	c      canvas
+	bill   *Bill
+	labels *slipLabels
+
+	// y is the position of the next line.
+	y float64
+}
+
+// drawPaymentSlip draws the payment slip (receipt and payment part) of b onto
+// c.
+func (b *Bill) drawPaymentSlip(c canvas, o *slipOptions) error {
+	l := &slipLayout{
+		c:      c,
+		bill:   b,
+		labels: slipTexts[o.lang],
+	}
+	l.receipt()
+	if err := l.paymentPart(); err != nil {
+		return err
+	}
+	if o.perforated {
+		return nil
+	}
+	// Separation line between receipt and payment part:
+	c.stroke(separatorWidth, separatorDash, point{receiptWidth, 0}, point{receiptWidth, slipHeight})
+	l.scissors(receiptWidth, 5, true)
+	if o.separateAbove {
+		c.stroke(separatorWidth, separatorDash, point{0, 0}, point{slipWidth, 0})
+		l.scissors(slipMargin, 0, false)
+	}
+	return nil
+}
+
+// scissors draws the scissors symbol onto the separation line at (x, y), with
+// the blades pointing along the line: to the right, or downwards if
+// vertical.
+func (l *slipLayout) scissors(x, y float64, vertical bool) {
+	// p converts a position relative to the center of the symbol, with u
+	// along and v across the separation line.
+	p := func(u, v float64) point {
+		if vertical {
+			return point{x - v, y + u}
+		}
+		return point{x + u, y + v}
+	}
+	// Interrupt the separation line:
+	l.c.fill(1, rect{x - 2, y - 2, 4, 4})
+	for _, side := range []float64{-1, 1} {
+		ring := p(-1.3, side*0.9)
+		l.c.circle(ring.x, ring.y, 0.55, separatorWidth)
+		l.c.stroke(1, 0, p(-0.8, side*0.6), p(2, -side*0.55))
+	}
+}
+
+// fit returns the length (in bytes) of the longest prefix of s which fits
+// into width, but at least one character.
+func (l *slipLayout) fit(s string, style fontStyle, size, width float64) int {
+	n := 0
+	for i, r := range s {
+		end := i + utf8.RuneLen(r)
+		if n > 0 && l.c.textWidth(style, size, s[:end]) > width {
+			break
+		}
+		n = end
+	}
+	return n
+}
+
+// wrap breaks s into lines which fit into width, at spaces where possible.
+func (l *slipLayout) wrap(s string, style fontStyle, size, width float64) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && l.c.textWidth(style, size, line+" "+word) <= width {
+			line += " " + word
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		for l.c.textWidth(style, size, word) > width {
+			n := l.fit(word, style, size, width)
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// ellipsize returns s followed by “...”, shortened to fit into width.
+func (l *slipLayout) ellipsize(s string, style fontStyle, size, width float64) string {
+	for s != "" && l.c.textWidth(style, size, s+"...") > width {
+		_, n := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-n]
+	}
+	return s + "..."
+}
+
+// shorten returns s, or s ellipsized if it does not fit into width.
+func (l *slipLayout) shorten(s string, style fontStyle, size, width float64) string {
+	if l.c.textWidth(style, size, s) <= width {
+		return s
+	}
+	return l.ellipsize(s, style, size, width)
+}
+
+func (l *slipLayout) title(x float64, title string) {
+	l.c.text(x, slipMargin+mm(titleSize), fontBold, titleSize, title)
+}
+
+// infoParagraph is a paragraph of the information section: a heading and
+// values, each wrapped into lines.
+type infoParagraph struct {
+	heading string
+	values  [][]string
+}
+
+// lines returns the number of lines of the values of p.
+func (p *infoParagraph) lines() int {
+	var n int
+	for _, v := range p.values {
+		n += len(v)
+	}
+	return n
+}
+
+// paragraph draws the heading and the lines of p.
+func (l *slipLayout) paragraph(x float64, s *partStyle, p *infoParagraph) {
+	l.c.text(x, s.baseline(l.y), fontBold, s.heading, p.heading)
+	l.y += mm(s.lineHeight)
+	for _, value := range p.values {
+		for _, line := range value {
+			l.c.text(x, s.baseline(l.y), fontRegular, s.value, line)
+			l.y += mm(s.lineHeight)
+		}
+	}
+	l.y += mm(s.gap)
+}
+
+// cornerMarks marks the corners of a blank field, to be filled in by hand.
+func (l *slipLayout) cornerMarks(r rect) {
+	const arm = 3
+	for _, c := range []struct{ x, y, dx, dy float64 }{
+		{r.x, r.y, 1, 1},
+		{r.x + r.w, r.y, -1, 1},
+		{r.x, r.y + r.h, 1, -1},
+		{r.x + r.w, r.y + r.h, -1, -1},
+	} {
+		l.c.stroke(separatorWidth, 0,
+			point{c.x + c.dx*arm, c.y},
+			point{c.x, c.y},
+			point{c.x, c.y + c.dy*arm})
+	}
+}
+
+// account returns the values of the “Account / Payable to” paragraph.
+func (l *slipLayout) account() []string {
+	q := l.bill.qrch
+	return append([]string{formatIBAN(q.CdtrInf.IBAN)}, addressLines(q.CdtrInf.Cdtr)...)
+}
+
+// information draws the paragraphs of the information section, down to the
+// debtor, whose blank field has the size of box. The section ends at bottom:
+// if it would not fit, the values with the most lines are shortened one line
+// at a time (e.g. long addresses are ellipsized instead of wrapped).
+func (l *slipLayout) information(x, width, bottom float64, s *partStyle, box rect) {
+	q := l.bill.qrch
+	wrap := func(values []string) [][]string {
+		var result [][]string
+		for _, v := range values {
+			if lines := l.wrap(v, fontRegular, s.value, width); len(lines) > 0 {
+				result = append(result, lines)
+			}
+		}
+		return result
+	}
+	paragraphs := []*infoParagraph{
+		{heading: l.labels.account, values: wrap(l.account())},
+	}
+	if ref := formatReference(q.RmtInf); ref != "" {
+		paragraphs = append(paragraphs, &infoParagraph{l.labels.reference, wrap([]string{ref})})
+	}
+	if s.maxAdditionalLines > 0 {
+		var lines []string
+		for _, info := range []string{q.RmtInf.AddInf.Ustrd, q.RmtInf.AddInf.StrdBkgInf} {
+			lines = append(lines, l.wrap(info, fontRegular, s.value, width)...)
+		}
+		if len(lines) > s.maxAdditionalLines {
+			lines = lines[:s.maxAdditionalLines]
+			last := &lines[len(lines)-1]
+			*last = l.ellipsize(*last, fontRegular, s.value, width)
+		}
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, &infoParagraph{l.labels.additionalInformation, [][]string{lines}})
+		}
+	}
+	blank := q.UltmtDbtr.empty()
+	if !blank {
+		paragraphs = append(paragraphs, &infoParagraph{l.labels.payableBy, wrap(addressLines(q.UltmtDbtr))})
+	}
+
+	height := func() float64 {
+		var h float64
+		for _, p := range paragraphs {
+			h += float64(1+p.lines())*mm(s.lineHeight) + mm(s.gap)
+		}
+		if blank {
+			return h + mm(s.lineHeight) + box.h
+		}
+		return h - mm(s.gap)
+	}
+	for l.y+height() > bottom {
+		var longest *[]string
+		for _, p := range paragraphs {
+			for idx := range p.values {
+				if longest == nil || len(p.values[idx]) > len(*longest) {
+					longest = &p.values[idx]
+				}
+			}
+		}
+		if len(*longest) <= 1 {
+			break // cannot be shortened any further
+		}
+		*longest = (*longest)[:len(*longest)-1]
+		last := &(*longest)[len(*longest)-1]
+		*last = l.ellipsize(*last, fontRegular, s.value, width)
+	}
+
+	for _, p := range paragraphs {
+		l.paragraph(x, s, p)
+	}
+	if blank {
+		l.c.text(x, s.baseline(l.y), fontBold, s.heading, l.labels.payableByNameAddress)
+		box.x, box.y = x, l.y+mm(s.lineHeight)
+		l.cornerMarks(box)
+	}
+}
+
+// amount draws the amount section at (x, y). The amount is printed
+// amountOffset to the right of the currency, or a blank field of the size of
+// box, aligned to the right of the section, if the amount is to be filled in
+// by the debtor.
+func (l *slipLayout) amount(x, y, width, amountOffset float64, s *partStyle, box rect) {
+	q := l.bill.qrch
+	l.c.text(x, s.baseline(y), fontBold, s.heading, l.labels.currency)
+	l.c.text(x+amountOffset, s.baseline(y), fontBold, s.heading, l.labels.amount)
+	y += mm(s.lineHeight)
+	l.c.text(x, s.baseline(y), fontRegular, s.value, string(q.CcyAmt.Ccy))
+	if amt := formatAmount(q.CcyAmt.Amt); amt != "" {
+		l.c.text(x+amountOffset, s.baseline(y), fontRegular, s.value, amt)
+		return
+	}
+	box.x, box.y = x+width-box.w, y
+	l.cornerMarks(box)
+}
+
+func (l *slipLayout) receipt() {
+	const (
+		x     = slipMargin
+		width = receiptWidth - 2*slipMargin
+	)
+	s := &receiptStyle
+	l.title(x, l.labels.receipt)
+	l.y = 12
+	l.information(x, width, amountY, s, rect{w: 52, h: 20})
+	l.amount(x, amountY, width, 12, s, rect{w: 30, h: 10})
+	w := l.c.textWidth(fontBold, s.heading, l.labels.acceptancePoint)
+	l.c.text(x+width-w, s.baseline(82), fontBold, s.heading, l.labels.acceptancePoint)
+}
+
+func (l *slipLayout) paymentPart() error {
+	const (
+		x = receiptWidth + slipMargin
+
+		// The information section is to the right of the Swiss QR Code and
+		// amount sections.
+		infoX     = x + 51
+		infoWidth = slipWidth - slipMargin - infoX
+	)
+	s := &paymentPartStyle
+	l.title(x, l.labels.paymentPart)
+	if err := l.qrCode(x, 17); err != nil {
+		return err
+	}
+	l.amount(x, amountY, infoX-x, 13, s, rect{w: 40, h: 15})
+	l.furtherInformation(x, 90, slipWidth-slipMargin-x)
+	l.y = slipMargin
+	l.information(infoX, infoWidth, slipHeight-slipMargin, s, rect{w: 65, h: 25})
+	return nil
+}
+
+// furtherInformation draws the parameters of the alternative procedures,
+// with the name of the procedure (up to the first separator) in bold.
+func (l *slipLayout) furtherInformation(x, y, width float64) {
+	const (
+		size       = 7
+		lineHeight = 8
+	)
+	for _, params := range l.bill.qrch.AltPmtInf.AltPmt {
+		name := params
+		if idx := strings.IndexAny(params, "/;"); idx > 0 {
+			name = params[:idx]
+		}
+		nameWidth := l.c.textWidth(fontBold, size, name)
+		rest := l.shorten(params[len(name):], fontRegular, size, width-nameWidth)
+		l.c.text(x, y+mm(size), fontBold, size, name)
+		l.c.text(x+nameWidth, y+mm(size), fontRegular, size, rest)
+		y += mm(lineHeight)
+	}
+}
+
+// qrCode draws the Swiss QR Code (without quiet zone) at (x, y).
+func (l *slipLayout) qrCode(x, y float64) error {
+	code, err := encoder.Encoder_encode(l.bill.qrcontents, decoder.ErrorCorrectionLevel_M, qrEncodeHints())
+	if err != nil {
+		return err
+	}
+	matrix := code.GetMatrix()
+	module := slipQRCodeSize / float64(matrix.GetWidth())
+	var modules []rect
+	for my := 0; my < matrix.GetHeight(); my++ {
+		for mx := 0; mx < matrix.GetWidth(); mx++ {
+			if matrix.Get(mx, my) == 1 {
+				modules = append(modules, rect{x + float64(mx)*module, y + float64(my)*module, module, module})
+			}
+		}
+	}
+	l.c.fill(0, modules...)
+
+	// Overlay the Swiss cross (7×7 mm) in the center, using the geometry of
+	// swisscross.svg:
+	const crossSize = 7
+	unit := float64(crossSize) / swissCrossEdgeSidePx
+	cx := x + (slipQRCodeSize-crossSize)/2
+	cy := y + (slipQRCodeSize-crossSize)/2
+	cross := func(x, y, w, h float64) rect {
+		return rect{cx + x*unit, cy + y*unit, w * unit, h * unit}
+	}
+	l.c.fill(1, cross(0, 0, 166, 166))
+	l.c.fill(0, cross(12, 12, 142, 142))
+	l.c.fill(1, cross(36, 66, 94, 28), cross(68, 34, 30, 92))
+	return nil
+}
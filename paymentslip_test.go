@@ -0,0 +1,486 @@
+package qrbill_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestEncodeToPaymentSlipPDF(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		modify  func(*qrbill.QRCH)
+		opts    []qrbill.SlipOption
+		want    []string
+		notWant []string
+	}{
+		{
+			desc: "default",
+			want: []string{
+				"/MediaBox [ 0 0 595.276 297.638 ]",
+				"/BaseFont /Helvetica-Bold",
+				"(Receipt) Tj",
+				"(Payment part) Tj",
+				"(Account / Payable to) Tj",
+				"(CH02 0900 0000 8709 1354 3) Tj",
+				"(Quellenstrasse 25) Tj",
+				"(8005 Z\xfcrich) Tj", // WinAnsiEncoding
+				"(Additional information) Tj",
+				"(Spende 420) Tj",
+				"(Payable by) Tj",
+				"(Stauffacherstr 42) Tj",
+				"(50.00) Tj",
+				"(Acceptance point) Tj",
+				"[2.835] 0 d", // dashed separation line
+			},
+			notWant: []string{
+				"(Reference) Tj",
+				"(Payable by \\(name/address\\)) Tj",
+			},
+		},
+
+		{
+			desc: "QR reference",
+			modify: func(q *qrbill.QRCH) {
+				q.CdtrInf.IBAN = "CH4431999123000889012"
+				q.RmtInf.Tp = qrbill.ReferenceTypeQRR
+				q.RmtInf.Ref = "210000000003139471430009017"
+				q.CcyAmt.Amt = "1949.75"
+			},
+			want: []string{
+				"(CH44 3199 9123 0008 8901 2) Tj",
+				"(Reference) Tj",
+				"(21 00000 00003 13947 14300 09017) Tj",
+				"(1 949.75) Tj",
+			},
+		},
+
+		{
+			desc: "Creditor Reference",
+			modify: func(q *qrbill.QRCH) {
+				q.RmtInf.Tp = qrbill.ReferenceTypeSCOR
+				q.RmtInf.Ref = "RF18539007547034"
+			},
+			want: []string{
+				"(RF18 5390 0754 7034) Tj",
+			},
+		},
+
+		{
+			desc: "blank fields",
+			modify: func(q *qrbill.QRCH) {
+				q.CcyAmt.Amt = ""
+				q.UltmtDbtr = qrbill.Address{}
+			},
+			want: []string{
+				"(Payable by \\(name/address\\)) Tj",
+			},
+			notWant: []string{
+				"(50.00) Tj",
+				"(Stauffacherstr 42) Tj",
+			},
+		},
+
+		{
+			desc: "foreign address",
+			modify: func(q *qrbill.QRCH) {
+				q.UltmtDbtr = qrbill.Address{
+					AdrTp:            qrbill.AddressTypeStructured,
+					Name:             "Max Muster",
+					StrtNmOrAdrLine1: "Marienplatz",
+					BldgNbOrAdrLine2: "1",
+					PstCd:            "80331",
+					TwnNm:            "München",
+					Ctry:             "DE",
+				}
+			},
+			want: []string{
+				"(DE-80331 M\xfcnchen) Tj",
+			},
+		},
+
+		{
+			desc: "long message",
+			modify: func(q *qrbill.QRCH) {
+				// 6 lines, of which only 4 are printed:
+				q.RmtInf.AddInf.Ustrd = strings.Repeat("WWWWWWW ", 17)
+			},
+			want: []string{
+				"(WWWWWWW WWWWWWW WWWWWWW) Tj",
+				"W...) Tj",
+			},
+		},
+
+		{
+			desc: "alternative procedure",
+			modify: func(q *qrbill.QRCH) {
+				q.AltPmtInf.AltPmt = []string{"eBill/B/41010560425610173"}
+			},
+			want: []string{
+				"/helveticabold 7 Tf",
+				"(eBill) Tj",
+				"(/B/41010560425610173) Tj",
+			},
+		},
+
+		{
+			desc: "German",
+			opts: []qrbill.SlipOption{qrbill.WithLanguage(qrbill.LanguageGerman)},
+			want: []string{
+				"(Empfangsschein) Tj",
+				"(Zahlteil) Tj",
+				"(Konto / Zahlbar an) Tj",
+				"(Zus\xe4tzliche Informationen) Tj",
+				"(Zahlbar durch) Tj",
+				"(W\xe4hrung) Tj",
+				"(Betrag) Tj",
+				"(Annahmestelle) Tj",
+			},
+			notWant: []string{
+				"(Receipt) Tj",
+			},
+		},
+
+		{
+			desc: "French",
+			modify: func(q *qrbill.QRCH) {
+				q.UltmtDbtr = qrbill.Address{}
+			},
+			opts: []qrbill.SlipOption{qrbill.WithLanguage(qrbill.LanguageFrench)},
+			want: []string{
+				"(R\xe9c\xe9piss\xe9) Tj",
+				"(Section paiement) Tj",
+				"(Payable par \\(nom/adresse\\)) Tj",
+				"(Point de d\xe9p\xf4t) Tj",
+			},
+		},
+
+		{
+			desc: "Italian",
+			opts: []qrbill.SlipOption{qrbill.WithLanguage(qrbill.LanguageItalian)},
+			want: []string{
+				"(Ricevuta) Tj",
+				"(Sezione pagamento) Tj",
+				"(Conto / Pagabile a) Tj",
+				"(Informazioni supplementari) Tj",
+				"(Punto di accettazione) Tj",
+			},
+		},
+
+		{
+			desc: "notification",
+			modify: func(q *qrbill.QRCH) {
+				if err := q.SetNotification(qrbill.LanguageEnglish); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{
+				"(0.00) Tj",
+				"(DO NOT USE FOR PAYMENT) Tj",
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			qrch := testQRCH()
+			if tt.modify != nil {
+				tt.modify(qrch)
+			}
+			bill, err := qrch.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := bill.EncodeToPaymentSlipPDF(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(b, []byte("%PDF-")) {
+				t.Errorf("EncodeToPaymentSlipPDF() does not start with a PDF header")
+			}
+			for _, want := range tt.want {
+				if !bytes.Contains(b, []byte(want)) {
+					t.Errorf("EncodeToPaymentSlipPDF() does not contain %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if bytes.Contains(b, []byte(notWant)) {
+					t.Errorf("EncodeToPaymentSlipPDF() unexpectedly contains %q", notWant)
+				}
+			}
+		})
+	}
+}
+
+var (
+	textRe   = regexp.MustCompile(`(?m)^BT /\S+ \S+ Tf (\S+) (\S+) Td (\(.*\)) Tj ET$`)
+	strokeRe = regexp.MustCompile(`(?m)^(\S+) (\S+) [ml]$`)
+)
+
+func TestPaymentSlipSectionLimits(t *testing.T) {
+	// wide returns a text of n characters in wide glyphs.
+	wide := func(n int) string {
+		return strings.TrimSpace(strings.Repeat("WMWMWMW ", n/8+1)[:n])
+	}
+	maxAddress := qrbill.Address{
+		AdrTp:            qrbill.AddressTypeStructured,
+		Name:             wide(70),
+		StrtNmOrAdrLine1: wide(70),
+		BldgNbOrAdrLine2: wide(16),
+		PstCd:            wide(16),
+		TwnNm:            wide(35),
+		Ctry:             "DE",
+	}
+	for _, tt := range []struct {
+		desc   string
+		debtor qrbill.Address
+	}{
+		{"debtor", maxAddress},
+		{"blank debtor", qrbill.Address{}},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			qrch := testQRCH()
+			qrch.CdtrInf.IBAN = "CH4431999123000889012"
+			qrch.CdtrInf.Cdtr = maxAddress
+			qrch.UltmtDbtr = tt.debtor
+			qrch.RmtInf.Tp = qrbill.ReferenceTypeQRR
+			qrch.RmtInf.Ref = "210000000003139471430009017"
+			qrch.RmtInf.AddInf.Ustrd = wide(140)
+			qrch.CcyAmt.Amt = "123.00"
+			bill, err := qrch.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := bill.EncodeToPaymentSlipPDF()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// position converts PDF coordinates to mm relative to the top
+			// left corner of the payment slip.
+			position := func(x, y string) (float64, float64) {
+				fx, _ := strconv.ParseFloat(x, 64)
+				fy, _ := strconv.ParseFloat(y, 64)
+				return fx * 25.4 / 72, 105 - fy*25.4/72
+			}
+			// check verifies that positions in the information sections are
+			// above the amount section (receipt) or the bottom margin
+			// (payment part).
+			check := func(what string, x, y float64) {
+				if x < 61 && y > 68 || x >= 118 && y > 100 {
+					t.Errorf("%s at (%.1f, %.1f) mm below the information section", what, x, y)
+				}
+			}
+			amountSection := map[string]bool{
+				"(Currency)": true, "(Amount)": true, "(CHF)": true, "(123.00)": true, "(Acceptance point)": true,
+			}
+			for _, m := range textRe.FindAllStringSubmatch(string(b), -1) {
+				if !amountSection[m[3]] {
+					x, y := position(m[1], m[2])
+					check(m[3], x, y)
+				}
+			}
+			// The corner marks of the blank field:
+			for _, m := range strokeRe.FindAllStringSubmatch(string(b), -1) {
+				x, y := position(m[1], m[2])
+				check("line", x, y)
+			}
+			for _, want := range []string{"(Payable by) Tj", "(Payable by \\(name/address\\)) Tj"} {
+				if bytes.Contains(b, []byte(want)) {
+					return
+				}
+			}
+			t.Errorf("EncodeToPaymentSlipPDF() contains no debtor")
+		})
+	}
+}
+
+func TestSlipLanguage(t *testing.T) {
+	bill, err := testQRCH().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bill.EncodeToPaymentSlipPDF(qrbill.WithLanguage("rm")); err == nil {
+		t.Errorf("EncodeToPaymentSlipPDF(rm) unexpectedly succeeded")
+	}
+	if _, err := bill.EncodeToA4PDF(nil, qrbill.WithLanguage("")); err == nil {
+		t.Errorf("EncodeToA4PDF() with empty language unexpectedly succeeded")
+	}
+	b, err := bill.EncodeToPaymentSlipSVG(qrbill.WithLanguage(qrbill.LanguageFrench))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ">Récépissé</text>"; !bytes.Contains(b, []byte(want)) {
+		t.Errorf("EncodeToPaymentSlipSVG(fr) does not contain %q", want)
+	}
+}
+
+func TestWithPDFFonts(t *testing.T) {
+	bill, err := testQRCH().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// See internal/pdf for tests with a valid TrueType font.
+	_, err = bill.EncodeToPaymentSlipPDF(qrbill.WithPDFFonts([]byte("not a font"), nil))
+	if want := "regular font: not a TrueType font"; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("EncodeToPaymentSlipPDF() = %v, want error %q", err, want)
+	}
+	if _, err := bill.EncodeToA4PDF(nil, qrbill.WithPDFFonts(nil, nil)); err != nil {
+		t.Errorf("EncodeToA4PDF() without fonts = %v", err)
+	}
+}
+
+func TestEncodeToPaymentSlipSVG(t *testing.T) {
+	qrch := testQRCH()
+	qrch.CcyAmt.Amt = ""
+	bill, err := qrch.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bill.EncodeToPaymentSlipSVG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Width  string `xml:"width,attr"`
+		Height string `xml:"height,attr"`
+		Texts  []struct {
+			FontSize   string `xml:"font-size,attr"`
+			FontWeight string `xml:"font-weight,attr"`
+			Text       string `xml:",chardata"`
+		} `xml:"g>text"`
+		Polylines []struct {
+			Dash string `xml:"stroke-dasharray,attr"`
+		} `xml:"g>polyline"`
+		Circles []struct{} `xml:"g>circle"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Width != "210.000mm" || doc.Height != "105.000mm" {
+		t.Errorf("size = %s × %s, want 210mm × 105mm", doc.Width, doc.Height)
+	}
+	texts := make(map[string]string)
+	for _, text := range doc.Texts {
+		texts[text.Text] = text.FontSize + " " + text.FontWeight
+	}
+	for _, tt := range []struct {
+		text string
+		want string // font size in mm and weight
+	}{
+		{"Receipt", "3.881 bold"},
+		{"Payment part", "3.881 bold"},
+		{"Acceptance point", "2.117 bold"},
+		{"Spende 420", "3.528 "},  // 10pt value
+		{"8004 Zürich", "3.528 "}, // 10pt value (payment part)
+		{"Additional information", "2.822 bold"},
+	} {
+		if got, ok := texts[tt.text]; !ok || got != tt.want {
+			t.Errorf("text %q: font = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	if _, ok := texts["50.00"]; ok {
+		t.Errorf("amount unexpectedly printed, want blank field")
+	}
+
+	var dashed, solid int
+	for _, p := range doc.Polylines {
+		if p.Dash != "" {
+			dashed++
+		} else {
+			solid++
+		}
+	}
+	if dashed != 1 {
+		t.Errorf("%d dashed separation lines, want 1", dashed)
+	}
+	// 4 corner marks per blank amount field (receipt and payment part), 2
+	// blades of the scissors symbol:
+	if want := 2*4 + 2; solid != want {
+		t.Errorf("%d solid lines, want %d", solid, want)
+	}
+	if got, want := len(doc.Circles), 2; got != want {
+		t.Errorf("%d circles (scissors symbol), want %d", got, want)
+	}
+}
+
+func TestEncodeToA4PDF(t *testing.T) {
+	bill, err := testQRCH().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		desc    string
+		top     func(*qrbill.A4Page)
+		opts    []qrbill.SlipOption
+		want    []string
+		notWant []string
+	}{
+		{
+			desc: "blank",
+			want: []string{
+				"/MediaBox [ 0 0 595.276 841.89 ]",
+				"(Payment part) Tj",
+				// Separation line above the payment slip:
+				"0 297.638 m\n595.276 297.638 l\n",
+			},
+			notWant: []string{
+				" re W n",
+			},
+		},
+
+		{
+			desc: "perforated paper",
+			opts: []qrbill.SlipOption{qrbill.WithPerforatedPaper()},
+			want: []string{
+				"(Payment part) Tj",
+			},
+			notWant: []string{
+				// Separation lines and scissors symbols:
+				"[2.835] 0 d",
+				"0 297.638 m\n595.276 297.638 l\n",
+				" c\n",
+			},
+		},
+
+		{
+			desc: "content",
+			top: func(p *qrbill.A4Page) {
+				p.Text(20, 30, 12, true, "Invoice 4711")
+				if got, want := p.TextWidth(12, true, "Invoice 4711"), 25.180; math.Abs(got-want) > 0.001 {
+					t.Errorf("TextWidth() = %v, want %v", got, want)
+				}
+				p.Line(20, 32, 190, 32, 0.5)
+				p.ContentStream([]byte("0 0 1 rg 56.693 566.929 100 50 re f"))
+			},
+			want: []string{
+				// Clipped to the part above the payment slip:
+				"0 297.638 595.276 544.252 re W n",
+				"BT /helveticabold 12 Tf 56.693 756.85 Td (Invoice 4711) Tj ET",
+				"56.693 751.181 m\n538.583 751.181 l\n",
+				"q\n0 0 1 rg 56.693 566.929 100 50 re f\nQ\n",
+				"(Payment part) Tj",
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := bill.EncodeToA4PDF(tt.top, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !bytes.Contains(b, []byte(want)) {
+					t.Errorf("EncodeToA4PDF() does not contain %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if bytes.Contains(b, []byte(notWant)) {
+					t.Errorf("EncodeToA4PDF() unexpectedly contains %q", notWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import "fmt"
+
+// DIN A4 page size in mm.
+const (
+	a4Width  = 210
+	a4Height = 297
+)
+
+// A4Page is the part of an A4 page above the payment slip, see
+// Bill.EncodeToA4PDF. Positions are in mm relative to the top left corner of
+// the page, font sizes and line widths in pt.
+type A4Page struct {
+	c *pdfCanvas
+}
+
+// Text draws s in Helvetica (or Helvetica Bold) with its baseline starting at
+// (x, y), or in the fonts embedded using WithPDFFonts. Characters outside of
+// Windows code page 1252 are printed without diacritics (e.g. č as c) unless
+// fonts are embedded.
+func (p *A4Page) Text(x, y, size float64, bold bool, s string) {
+	p.c.text(x, y, p.style(bold), size, s)
+}
+
+// TextWidth returns the width of s in mm, e.g. for right-aligning text.
+func (p *A4Page) TextWidth(size float64, bold bool, s string) float64 {
+	return p.c.textWidth(p.style(bold), size, s)
+}
+
+func (p *A4Page) style(bold bool) fontStyle {
+	if bold {
+		return fontBold
+	}
+	return fontRegular
+}
+
+// Line draws a black line from (x1, y1) to (x2, y2).
+func (p *A4Page) Line(x1, y1, x2, y2, width float64) {
+	p.c.stroke(width, 0, point{x1, y1}, point{x2, y2})
+}
+
+// ContentStream appends PDF content stream operators, e.g. as produced by
+// another PDF library. Unlike the other methods, content uses the PDF
+// coordinate system: positions are in pt relative to the bottom left corner
+// of the page. The fonts used by Text are available as /helvetica and
+// /helveticabold, or /regular and /bold with WithPDFFonts.
+func (p *A4Page) ContentStream(content []byte) {
+	p.c.content.WriteString("q\n")
+	p.c.content.Write(content)
+	p.c.content.WriteString("\nQ\n")
+}
+
+// EncodeToA4PDF returns a PDF document with a single A4 page (210×297 mm),
+// with the payment slip (see EncodeToPaymentSlipPDF) in the bottom 105 mm,
+// e.g. for printing on paper with a perforation at that position. A
+// separation line is drawn above the payment slip unless WithPerforatedPaper
+// is used.
+//
+// The part above the payment slip is drawn by top, which may be nil to leave
+// it blank. Anything drawn by top is clipped to that part.
+func (b *Bill) EncodeToA4PDF(top func(*A4Page), opts ...SlipOption) ([]byte, error) {
+	o, err := newSlipOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	o.separateAbove = true
+	c, err := newPDFCanvas(o)
+	if err != nil {
+		return nil, err
+	}
+	if top != nil {
+		c.top = a4Height
+		c.content.WriteString("q\n")
+		// Clip to the part above the payment slip:
+		fmt.Fprintf(&c.content, "%s %s %s %s re W n\n", pt(0), pt(slipHeight), pt(a4Width), pt(a4Height-slipHeight))
+		top(&A4Page{c: c})
+		c.content.WriteString("Q\n")
+		c.top = slipHeight
+	}
+	if err := b.drawPaymentSlip(c, o); err != nil {
+		return nil, err
+	}
+	return c.document(a4Width, a4Height)
+}
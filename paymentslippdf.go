@@ -0,0 +1,192 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stapelberg/qrbill/internal/pdf"
+)
+
+// pdfCanvas implements canvas by writing a PDF content stream.
+type pdfCanvas struct {
+	content strings.Builder
+	fonts   map[fontStyle]pdf.TextFont
+
+	// top is the distance (in mm) between the bottom of the page and y=0,
+	// i.e. the height of the payment slip unless drawing above it.
+	top float64
+}
+
+func newPDFCanvas(o *slipOptions) (*pdfCanvas, error) {
+	c := &pdfCanvas{
+		top: slipHeight,
+		fonts: map[fontStyle]pdf.TextFont{
+			fontRegular: &pdf.Font{
+				Common:   pdf.Common{ObjectName: "helvetica"},
+				BaseFont: pdf.Helvetica,
+			},
+			fontBold: &pdf.Font{
+				Common:   pdf.Common{ObjectName: "helveticabold"},
+				BaseFont: pdf.HelveticaBold,
+			},
+		},
+	}
+	if o.regularFont == nil && o.boldFont == nil {
+		return c, nil
+	}
+	for _, f := range []struct {
+		style fontStyle
+		name  string
+		data  []byte
+	}{
+		{fontRegular, "regular", o.regularFont},
+		{fontBold, "bold", o.boldFont},
+	} {
+		ttf, err := pdf.ParseTrueType(f.data)
+		if err != nil {
+			return nil, fmt.Errorf("%s font: %v", f.name, err)
+		}
+		ttf.ObjectName = f.name
+		c.fonts[f.style] = ttf
+	}
+	return c, nil
+}
+
+// pt converts mm to pt (1/72 inch).
+func pt(mm float64) string {
+	return pdf.Number(mm * 72 / 25.4)
+}
+
+// y converts y (relative to the top of the payment slip) to the PDF
+// coordinate system, whose origin is at the bottom left corner.
+func (c *pdfCanvas) y(y float64) string {
+	return pt(c.top - y)
+}
+
+func (c *pdfCanvas) text(x, y float64, style fontStyle, size float64, s string) {
+	c.content.WriteString(pdf.ShowText(c.fonts[style], size, x*72/25.4, (c.top-y)*72/25.4, s))
+}
+
+func (c *pdfCanvas) textWidth(style fontStyle, size float64, s string) float64 {
+	return mm(c.fonts[style].Width(s, size))
+}
+
+func (c *pdfCanvas) stroke(width, dash float64, points ...point) {
+	fmt.Fprintf(&c.content, "q %s w\n", pdf.Number(width))
+	if dash > 0 {
+		fmt.Fprintf(&c.content, "[%s] 0 d\n", pt(dash))
+	}
+	for idx, p := range points {
+		op := "l"
+		if idx == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&c.content, "%s %s %s\n", pt(p.x), c.y(p.y), op)
+	}
+	c.content.WriteString("S Q\n")
+}
+
+func (c *pdfCanvas) circle(x, y, r, width float64) {
+	// Approximate the circle using four Bézier curves, see
+	// https://spencermortensen.com/articles/bezier-circle/
+	const k = 0.5523
+	fmt.Fprintf(&c.content, "q %s w\n%s %s m\n", pdf.Number(width), pt(x+r), c.y(y))
+	for _, q := range [][2]float64{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}} {
+		// One curve per quadrant, counter-clockwise:
+		dx, dy := q[0], q[1]
+		if dx*dy > 0 {
+			fmt.Fprintf(&c.content, "%s %s %s %s %s %s c\n",
+				pt(x+dx*r), c.y(y+dy*k*r), pt(x+dx*k*r), c.y(y+dy*r), pt(x), c.y(y+dy*r))
+		} else {
+			fmt.Fprintf(&c.content, "%s %s %s %s %s %s c\n",
+				pt(x+dx*k*r), c.y(y+dy*r), pt(x+dx*r), c.y(y+dy*k*r), pt(x+dx*r), c.y(y))
+		}
+	}
+	c.content.WriteString("S Q\n")
+}
+
+func (c *pdfCanvas) fill(gray float64, rects ...rect) {
+	fmt.Fprintf(&c.content, "%s g\n", pdf.Number(gray))
+	for _, r := range rects {
+		fmt.Fprintf(&c.content, "%s %s %s %s re\n", pt(r.x), c.y(r.y+r.h), pt(r.w), pt(r.h))
+	}
+	// Filling the whole path at once prevents rendering artifacts between
+	// the modules of the QR code, see renderResultPDF.
+	c.content.WriteString("f\n")
+}
+
+// document returns a PDF document with a single page of the specified size
+// (in mm), containing the content stream of c.
+func (c *pdfCanvas) document(width, height float64) ([]byte, error) {
+	page := &pdf.Page{
+		Common:   pdf.Common{ObjectName: "page0"},
+		MediaBox: [4]float64{0, 0, width * 72 / 25.4, height * 72 / 25.4},
+		Resources: []pdf.Object{
+			c.fonts[fontRegular],
+			c.fonts[fontBold],
+		},
+		Parent: "pages",
+		Contents: []pdf.Object{
+			&pdf.Common{
+				ObjectName: "content0",
+				Stream:     []byte("q\n" + c.content.String() + "Q\n"),
+			},
+		},
+	}
+	doc := &pdf.Catalog{
+		Common: pdf.Common{ObjectName: "catalog"},
+		Pages: &pdf.Pages{
+			Common: pdf.Common{ObjectName: "pages"},
+			Kids:   []pdf.Object{page},
+		},
+	}
+	info := &pdf.DocumentInfo{
+		Common:       pdf.Common{ObjectName: "info"},
+		CreationDate: time.Now(),
+		Producer:     "https://github.com/stapelberg/qrbill",
+		Title:        "QR-Bill",
+	}
+	var buf bytes.Buffer
+	if err := pdf.NewEncoder(&buf).Encode(doc, info); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeToPaymentSlipPDF returns a PDF document containing the payment slip
+// (210×105 mm): the receipt on the left and the payment part, including the
+// Swiss QR Code, on the right.
+//
+// The text is set in Helvetica, using the fonts provided by the PDF viewer,
+// unless fonts are embedded using WithPDFFonts. Characters outside of Windows
+// code page 1252 are then printed without diacritics (e.g. č as c).
+func (b *Bill) EncodeToPaymentSlipPDF(opts ...SlipOption) ([]byte, error) {
+	o, err := newSlipOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newPDFCanvas(o)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.drawPaymentSlip(c, o); err != nil {
+		return nil, err
+	}
+	return c.document(slipWidth, slipHeight)
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	svg "github.com/ajstarks/svgo/float"
+	"github.com/stapelberg/qrbill/internal/pdf"
+)
+
+// svgCanvas implements canvas by writing SVG elements, in mm.
+type svgCanvas struct {
+	s *svg.SVG
+
+	// metrics are used for measuring text. The SVG document requests
+	// Helvetica, or the metrically compatible Arial.
+	metrics map[fontStyle]*pdf.Font
+}
+
+func newSVGCanvas(s *svg.SVG) *svgCanvas {
+	return &svgCanvas{
+		s: s,
+		metrics: map[fontStyle]*pdf.Font{
+			fontRegular: {BaseFont: pdf.Helvetica},
+			fontBold:    {BaseFont: pdf.HelveticaBold},
+		},
+	}
+}
+
+func (c *svgCanvas) text(x, y float64, style fontStyle, size float64, s string) {
+	attrs := []string{fmt.Sprintf(`font-size="%.3f"`, mm(size))}
+	if style == fontBold {
+		attrs = append(attrs, `font-weight="bold"`)
+	}
+	c.s.Text(x, y, s, attrs...)
+}
+
+func (c *svgCanvas) textWidth(style fontStyle, size float64, s string) float64 {
+	return mm(c.metrics[style].Width(s, size))
+}
+
+func (c *svgCanvas) stroke(width, dash float64, points ...point) {
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for idx, p := range points {
+		xs[idx], ys[idx] = p.x, p.y
+	}
+	attrs := []string{`fill="none"`, `stroke="black"`, fmt.Sprintf(`stroke-width="%.3f"`, mm(width))}
+	if dash > 0 {
+		attrs = append(attrs, fmt.Sprintf(`stroke-dasharray="%g"`, dash))
+	}
+	c.s.Polyline(xs, ys, attrs...)
+}
+
+func (c *svgCanvas) circle(x, y, r, width float64) {
+	c.s.Circle(x, y, r, `fill="none"`, `stroke="black"`, fmt.Sprintf(`stroke-width="%.3f"`, mm(width)))
+}
+
+func (c *svgCanvas) fill(gray float64, rects ...rect) {
+	var d strings.Builder
+	for _, r := range rects {
+		fmt.Fprintf(&d, "M%.3f %.3fh%.3fv%.3fh%.3fz", r.x, r.y, r.w, r.h, -r.w)
+	}
+	g := int(gray * 255)
+	c.s.Path(d.String(), fmt.Sprintf(`fill="rgb(%d,%d,%d)"`, g, g, g), `shape-rendering="crispEdges"`)
+}
+
+// EncodeToPaymentSlipSVG returns an SVG document containing the payment slip
+// (210×105 mm), see EncodeToPaymentSlipPDF. All dimensions are in mm.
+//
+// The text is set in Helvetica (or Arial) and measured using the metrics of
+// Helvetica.
+func (b *Bill) EncodeToPaymentSlipSVG(opts ...SlipOption) ([]byte, error) {
+	o, err := newSlipOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	s := svg.New(&buf)
+	s.Decimals = 3
+	s.StartviewUnit(slipWidth, slipHeight, "mm", 0, 0, slipWidth, slipHeight)
+	s.Rect(0, 0, slipWidth, slipHeight, `fill="white"`)
+	s.Group(`font-family="Helvetica, Arial, sans-serif"`)
+	if err := b.drawPaymentSlip(newSVGCanvas(s), o); err != nil {
+		return nil, err
+	}
+	s.Gend()
+	s.End()
+	return buf.Bytes(), nil
+}
@@ -21,19 +21,40 @@
 // https://www.six-group.com/dam/download/banking-services/standardization/qr-bill/ig-qr-bill-v2.1-en.pdf (English)
 // https://www.six-group.com/dam/download/banking-services/standardization/qr-bill/ig-qr-bill-v2.1-de.pdf (German)
 //
-// # Note
+// From November 2025, version 2.3 of the Implementation Guidelines no longer
+// permits combined addresses. Its rules can be selected using
+// WithSpecVersion(SpecVersion23). Existing combined addresses can be converted
+// using Address.Structured.
 //
-// QRR and SCOR references are not yet implemented.
+// # References
+//
+// QR references (QRR) can be created using QRReference, Creditor References
+// (SCOR) using CreditorReference.
+//
+// # JSON
+//
+// QRCH implements json.Marshaler and json.Unmarshaler using a stable,
+// versioned representation, which is described by the JSON Schema document
+// qrbill.schema.json.
+//
+// # Payment slip
+//
+// Besides the Swiss QR Code itself (e.g. Bill.EncodeToPDF), the complete
+// payment slip of 210×105 mm, consisting of the receipt and the payment part,
+// can be rendered using Bill.EncodeToPaymentSlipPDF or
+// Bill.EncodeToPaymentSlipSVG. Bill.EncodeToA4PDF places it at the bottom of
+// an A4 page, e.g. below the invoice. PDF documents use Helvetica unless
+// TrueType fonts are embedded using WithPDFFonts.
 package qrbill
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
-	"log"
 	"regexp"
-	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/makiuchi-d/gozxing/qrcode/decoder"
 	"github.com/makiuchi-d/gozxing/qrcode/encoder"
@@ -85,32 +106,57 @@ type Address struct {
 	Ctry             string // Country, two-digit country code according to ISO 3166-1
 }
 
-func (a Address) Validate() Address {
-	c := a
-
-	if v := c.Name; len(v) > 70 {
-		c.Name = v[:70]
-	}
-
-	if v := c.StrtNmOrAdrLine1; len(v) > 70 {
-		c.StrtNmOrAdrLine1 = v[:70]
+// truncate returns the first max characters (not bytes) of s, so that
+// multi-byte characters (e.g. ü) are never cut in half.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
 	}
+	runes := []rune(s)
+	return string(runes[:max])
+}
 
-	if v := c.BldgNbOrAdrLine2; len(v) > 16 {
-		c.BldgNbOrAdrLine2 = v[:16]
-	}
+// empty reports whether no field of the address is set.
+func (a Address) empty() bool {
+	return a == Address{}
+}
 
-	if v := c.PstCd; len(v) > 16 {
-		c.PstCd = v[:16]
-	}
+// Validate returns a copy of the address with the country code upper-cased,
+// the country code prefix removed from the postal code (e.g. CH-8004 becomes
+// 8004) and overlong fields truncated. Use ValidateStrict to check the rules
+// which cannot be fixed automatically, e.g. missing fields.
+func (a Address) Validate() Address {
+	c := a
 
-	if v := c.TwnNm; len(v) > 35 {
-		c.TwnNm = v[:35]
+	c.Ctry = strings.ToUpper(strings.TrimSpace(c.Ctry))
+	c.PstCd = countryPrefixRe.ReplaceAllString(strings.TrimSpace(c.PstCd), "$1")
+
+	c.Name = truncate(c.Name, 70)
+	c.StrtNmOrAdrLine1 = truncate(c.StrtNmOrAdrLine1, 70)
+	// The building number of structured addresses is limited to 16
+	// characters, whereas combined addresses contain postal code and town:
+	if c.AdrTp == AddressTypeStructured {
+		c.BldgNbOrAdrLine2 = truncate(c.BldgNbOrAdrLine2, 16)
+	} else {
+		c.BldgNbOrAdrLine2 = truncate(c.BldgNbOrAdrLine2, 70)
 	}
+	c.PstCd = truncate(c.PstCd, 16)
+	c.TwnNm = truncate(c.TwnNm, 35)
 
 	return c
 }
 
+// withPermittedChars returns a copy of the address with all characters
+// which are not permitted in the selected version transliterated.
+func (a Address) withPermittedChars(o *options) Address {
+	a.Name = o.version.normalizeChars(a.Name)
+	a.StrtNmOrAdrLine1 = o.version.normalizeChars(a.StrtNmOrAdrLine1)
+	a.BldgNbOrAdrLine2 = o.version.normalizeChars(a.BldgNbOrAdrLine2)
+	a.PstCd = o.version.normalizeChars(a.PstCd)
+	a.TwnNm = o.version.normalizeChars(a.TwnNm)
+	return a
+}
+
 type QRCHHeader struct {
 	QRType  string
 	Version string
@@ -122,19 +168,36 @@ type QRCHCdtrInf struct {
 	Cdtr Address // Creditor
 }
 
+// Currency is the currency of the amount. Only CHF and EUR are permitted.
+type Currency string
+
+const (
+	CurrencyCHF Currency = "CHF"
+	CurrencyEUR Currency = "EUR"
+)
+
+// Validate returns an error if c is not a permitted currency.
+func (c Currency) Validate() error {
+	if c != CurrencyCHF && c != CurrencyEUR {
+		return fmt.Errorf("currency %q not supported, must be %s or %s", string(c), CurrencyCHF, CurrencyEUR)
+	}
+	return nil
+}
+
 type QRCHCcyAmt struct {
-	Amt string // Amount
-	Ccy string // Currency
+	Amt string   // Amount
+	Ccy Currency // Currency
 }
 
 func (a QRCHCcyAmt) Validate() QRCHCcyAmt {
 	c := a
 
 	if c.Amt != "" {
-		parsed, err := strconv.ParseFloat(c.Amt, 64)
-		if err != nil {
-			log.Printf("ParseFloat(%q): %v", c.Amt, err)
-		}
+		// Invalid amounts, including amounts with more than two decimal
+		// places, are left unchanged, so that ValidateStrict reports them
+		// instead of encoding a different amount (e.g. 0.00 or a rounded
+		// amount).
+		parsed, err := ParseAmount(c.Amt)
 
 		// The Swiss Payment Standards 2019 Swiss Implementation Guidelines
 		// QR-bill Version 2.3 explains:
@@ -152,15 +215,20 @@ func (a QRCHCcyAmt) Validate() QRCHCcyAmt {
 		//
 		// Some banking apps are picky regarding integer numbers (e.g. 50) and
 		// require a separator plus two digits (e.g. 50.00).
-		c.Amt = fmt.Sprintf("%.2f", parsed)
+		if err == nil {
+			c.Amt = parsed.String()
+		}
 	}
 
+	c.Ccy = Currency(strings.ToUpper(strings.TrimSpace(string(c.Ccy))))
+
 	return c
 }
 
 type QRCHRmtInfAddInf struct {
-	Ustrd   string // Unstructured message
-	Trailer string // Trailer
+	Ustrd      string // Unstructured message
+	Trailer    string // Trailer
+	StrdBkgInf string // Bill information
 }
 
 type QRCHRmtInf struct {
@@ -169,13 +237,19 @@ type QRCHRmtInf struct {
 	AddInf QRCHRmtInfAddInf // Additional information
 }
 
+// QRCHAltPmtInf contains the parameters of alternative procedures (e.g. eBill).
+type QRCHAltPmtInf struct {
+	AltPmt []string // Alternative procedure parameters, max. 2 entries of max. 100 chars
+}
+
 type QRCH struct {
-	Header    QRCHHeader  // Header
-	CdtrInf   QRCHCdtrInf // Creditor information (Account / Payable to)
-	UltmtCdtr Address     // (must not be filled in, for Future Use)
-	CcyAmt    QRCHCcyAmt  // Paymount amount information
-	UltmtDbtr Address     // Ultimate Debtor
-	RmtInf    QRCHRmtInf  // Payment reference
+	Header    QRCHHeader    // Header
+	CdtrInf   QRCHCdtrInf   // Creditor information (Account / Payable to)
+	UltmtCdtr Address       // (must not be filled in, for Future Use)
+	CcyAmt    QRCHCcyAmt    // Paymount amount information
+	UltmtDbtr Address       // Ultimate Debtor
+	RmtInf    QRCHRmtInf    // Payment reference
+	AltPmtInf QRCHAltPmtInf // Alternative procedures
 }
 
 var (
@@ -189,19 +263,58 @@ var (
 	ustrdRe = regexp.MustCompile(`([a-zA-Z0-9\.,;:'\+\-/\(\)?\*\[\]\{\}\\` + "`" + `´~ ]|[!"#%&<>÷=@_$£]|[àáâäçèéêëìíîïñòóôöùúûüýßÀÁÂÄÇÈÉÊËÌÍÎÏÒÓÔÖÙÚÛÜÑ])`)
 )
 
-func (q *QRCH) Validate() *QRCH {
+// withFixedValues returns a copy of q with all fixed values filled in.
+func (q *QRCH) withFixedValues() *QRCH {
 	clone := &QRCH{}
 	*clone = *q
 
-	// Fill in all fixed values:
 	clone.Header.QRType = QRType
 	clone.Header.Version = Version
 	clone.Header.Coding = CodingType
 	clone.RmtInf.AddInf.Trailer = Trailer
 
-	// TODO(spec): strictly speaking, we need to restrict ourselves only to
-	// permitted characters (see below). But, even the example from SIX does not
-	// do that (Monatspr_ä_mie):
+	return clone
+}
+
+// withPermittedChars returns a copy of q with all characters which are not
+// permitted removed (IBAN, reference) or transliterated (text fields). This
+// is the first step of Validate.
+func (q *QRCH) withPermittedChars(o *options) *QRCH {
+	clone := &QRCH{}
+	*clone = *q
+
+	clone.CdtrInf.IBAN = strings.ToUpper(nonAlphanumericRe.ReplaceAllString(clone.CdtrInf.IBAN, ""))
+	clone.CdtrInf.Cdtr = clone.CdtrInf.Cdtr.withPermittedChars(o)
+	clone.UltmtCdtr = clone.UltmtCdtr.withPermittedChars(o)
+	clone.UltmtDbtr = clone.UltmtDbtr.withPermittedChars(o)
+	clone.RmtInf.Tp = nonAlphanumericRe.ReplaceAllString(clone.RmtInf.Tp, "")
+	clone.RmtInf.Ref = nonAlphanumericRe.ReplaceAllString(clone.RmtInf.Ref, "")
+	clone.RmtInf.AddInf.StrdBkgInf = o.version.normalizeChars(clone.RmtInf.AddInf.StrdBkgInf)
+	clone.RmtInf.AddInf.Ustrd = o.version.normalizeChars(clone.RmtInf.AddInf.Ustrd)
+	// Copy the slice, the original belongs to q:
+	clone.AltPmtInf.AltPmt = nil
+	for _, v := range q.AltPmtInf.AltPmt {
+		clone.AltPmtInf.AltPmt = append(clone.AltPmtInf.AltPmt, o.version.normalizeChars(v))
+	}
+
+	return clone
+}
+
+// Validate returns a copy of q with all fixed values filled in and all fields
+// normalized: invalid characters are stripped and overlong values truncated.
+// Use WithSpecVersion to apply the rules of a newer version of the
+// Implementation Guidelines.
+func (q *QRCH) Validate(opts ...Option) *QRCH {
+	o := newOptions(opts)
+
+	// Fill in all fixed values and replace characters which are not
+	// permitted:
+	clone := q.withFixedValues().withPermittedChars(o)
+
+	// Characters which are not permitted (see below) are transliterated to
+	// the closest permitted characters, see normalizeChars. Note that even the
+	// example from SIX does not restrict itself to the basic latin character
+	// set (Monatspr_ä_mie):
 	// https://www.moneytoday.ch/lexikon/qr-rechnung/
 
 	// 4.3.2 Permitted characters
@@ -220,8 +333,6 @@ func (q *QRCH) Validate() *QRCH {
 
 	// Enforce all field constraints:
 
-	clone.CdtrInf.IBAN = nonAlphanumericRe.ReplaceAllString(clone.CdtrInf.IBAN, "")
-
 	clone.CdtrInf.Cdtr = clone.CdtrInf.Cdtr.Validate()
 
 	clone.UltmtCdtr = clone.UltmtCdtr.Validate()
@@ -230,75 +341,192 @@ func (q *QRCH) Validate() *QRCH {
 
 	clone.CcyAmt = clone.CcyAmt.Validate()
 
-	clone.RmtInf.Tp = nonAlphanumericRe.ReplaceAllString(clone.RmtInf.Tp, "")
-	if v := clone.RmtInf.Tp; len(v) > 4 {
-		clone.RmtInf.Tp = v[:4]
-	}
+	clone.RmtInf.Tp = truncate(clone.RmtInf.Tp, 4)
 
-	clone.RmtInf.Ref = nonAlphanumericRe.ReplaceAllString(clone.RmtInf.Ref, "")
-	if v := clone.RmtInf.Ref; len(v) > 27 {
-		clone.RmtInf.Ref = v[:27]
+	maxRefLen := qrReferenceLen
+	if clone.RmtInf.Tp == ReferenceTypeSCOR {
+		// Creditor References are case-insensitive, but are printed in upper
+		// case by convention.
+		clone.RmtInf.Ref = strings.ToUpper(clone.RmtInf.Ref)
+		maxRefLen = creditorReferenceMaxLen
 	}
+	clone.RmtInf.Ref = truncate(clone.RmtInf.Ref, maxRefLen)
+
+	strdBkgInf := truncate(clone.RmtInf.AddInf.StrdBkgInf, 140)
+	clone.RmtInf.AddInf.StrdBkgInf = strdBkgInf
 
 	ustrd := clone.RmtInf.AddInf.Ustrd
-	matches := ustrdRe.FindAllString(ustrd, -1)
-	ustrd = strings.Join(matches, "")
 
-	if len(ustrd) > 140 {
-		ustrd = ustrd[:140]
-	}
+	// The unstructured message and the bill information share 140
+	// characters. Truncate the unstructured message, as the bill information
+	// is structured and cannot be shortened safely:
+	ustrd = truncate(ustrd, 140-utf8.RuneCountInString(strdBkgInf))
 	clone.RmtInf.AddInf.Ustrd = ustrd
 
+	altPmt := clone.AltPmtInf.AltPmt
+	if len(altPmt) > 2 {
+		altPmt = altPmt[:2]
+	}
+	for idx, v := range altPmt {
+		altPmt[idx] = truncate(v, 100)
+	}
+	clone.AltPmtInf.AltPmt = altPmt
+
 	return clone
 }
 
-func (q *QRCH) Encode() (*Bill, error) {
-	f := q.Validate()
+// Encode encodes q into a Bill.
+//
+// By default, q is normalized using Validate first, and only violations which
+// Validate cannot fix are returned as *ValidationError. Note that this rejects
+// input which earlier versions encoded anyway, e.g. a structured debtor
+// address without name, postal code or town. With WithStrictValidation, q is
+// encoded unmodified if it passes ValidateStrict. WithSpecVersion selects the
+// version of the Implementation Guidelines.
+func (q *QRCH) Encode(opts ...Option) (*Bill, error) {
+	o := newOptions(opts)
+	var (
+		f       *QRCH
+		changes []Change
+	)
+	if o.strict {
+		if err := q.ValidateStrict(opts...); err != nil {
+			return nil, err
+		}
+		f = q.withFixedValues()
+	} else {
+		f, changes = q.Normalize(opts...)
+		if err := f.ValidateStrict(opts...); err != nil {
+			return nil, err
+		}
+	}
+	lines := []string{
+		f.Header.QRType,
+		f.Header.Version,
+		f.Header.Coding,
+
+		f.CdtrInf.IBAN,
+
+		string(f.CdtrInf.Cdtr.AdrTp),
+		f.CdtrInf.Cdtr.Name,
+		f.CdtrInf.Cdtr.StrtNmOrAdrLine1,
+		f.CdtrInf.Cdtr.BldgNbOrAdrLine2,
+		f.CdtrInf.Cdtr.PstCd,
+		f.CdtrInf.Cdtr.TwnNm,
+		f.CdtrInf.Cdtr.Ctry,
+
+		string(f.UltmtCdtr.AdrTp),
+		f.UltmtCdtr.Name,
+		f.UltmtCdtr.StrtNmOrAdrLine1,
+		f.UltmtCdtr.BldgNbOrAdrLine2,
+		f.UltmtCdtr.PstCd,
+		f.UltmtCdtr.TwnNm,
+		f.UltmtCdtr.Ctry,
+
+		f.CcyAmt.Amt,
+		string(f.CcyAmt.Ccy),
+
+		string(f.UltmtDbtr.AdrTp),
+		f.UltmtDbtr.Name,
+		f.UltmtDbtr.StrtNmOrAdrLine1,
+		f.UltmtDbtr.BldgNbOrAdrLine2,
+		f.UltmtDbtr.PstCd,
+		f.UltmtDbtr.TwnNm,
+		f.UltmtDbtr.Ctry,
+
+		f.RmtInf.Tp,
+		f.RmtInf.Ref,
+		f.RmtInf.AddInf.Ustrd,
+		f.RmtInf.AddInf.Trailer,
+	}
+	// The optional elements are positional: the bill information must be
+	// present (possibly empty) if alternative procedures follow.
+	if f.RmtInf.AddInf.StrdBkgInf != "" || len(f.AltPmtInf.AltPmt) > 0 {
+		lines = append(lines, f.RmtInf.AddInf.StrdBkgInf)
+	}
+	lines = append(lines, f.AltPmtInf.AltPmt...)
+	payload := strings.Join(lines, "\n") + "\n"
+
+	// As per section 4.1, the payload must not exceed 997 characters and the
+	// Swiss QR Code must not exceed version 25 with error correction level M:
+	payloadLen := utf8.RuneCountInString(payload)
+	if payloadLen > MaxPayloadLength {
+		return nil, fmt.Errorf("%w: %d characters, max. %d", ErrPayloadTooLong, payloadLen, MaxPayloadLength)
+	}
+	code, err := encoder.Encoder_encode(payload, decoder.ErrorCorrectionLevel_M, qrEncodeHints())
+	if err != nil {
+		return nil, err
+	}
+	version := code.GetVersion().GetVersionNumber()
+	if version > MaxQRVersion {
+		return nil, fmt.Errorf("%w: version %d, max. %d", ErrQRVersionTooHigh, version, MaxQRVersion)
+	}
+
 	return &Bill{
-		qrcontents: strings.Join([]string{
-			f.Header.QRType,
-			f.Header.Version,
-			f.Header.Coding,
-
-			f.CdtrInf.IBAN,
-
-			string(f.CdtrInf.Cdtr.AdrTp),
-			f.CdtrInf.Cdtr.Name,
-			f.CdtrInf.Cdtr.StrtNmOrAdrLine1,
-			f.CdtrInf.Cdtr.BldgNbOrAdrLine2,
-			f.CdtrInf.Cdtr.PstCd,
-			f.CdtrInf.Cdtr.TwnNm,
-			f.CdtrInf.Cdtr.Ctry,
-
-			string(f.UltmtCdtr.AdrTp),
-			f.UltmtCdtr.Name,
-			f.UltmtCdtr.StrtNmOrAdrLine1,
-			f.UltmtCdtr.BldgNbOrAdrLine2,
-			f.UltmtCdtr.PstCd,
-			f.UltmtCdtr.TwnNm,
-			f.UltmtCdtr.Ctry,
-
-			f.CcyAmt.Amt,
-			f.CcyAmt.Ccy,
-
-			string(f.UltmtDbtr.AdrTp),
-			f.UltmtDbtr.Name,
-			f.UltmtDbtr.StrtNmOrAdrLine1,
-			f.UltmtDbtr.BldgNbOrAdrLine2,
-			f.UltmtDbtr.PstCd,
-			f.UltmtDbtr.TwnNm,
-			f.UltmtDbtr.Ctry,
-
-			f.RmtInf.Tp,
-			f.RmtInf.Ref,
-			f.RmtInf.AddInf.Ustrd,
-			f.RmtInf.AddInf.Trailer,
-		}, "\n") + "\n",
+		qrcontents: payload,
+		payloadLen: payloadLen,
+		qrVersion:  version,
+		qrch:       f,
+		changes:    changes,
 	}, nil
 }
 
+// Limits of the Swiss QR Code as per section 4.1 of the Implementation
+// Guidelines.
+const (
+	MaxPayloadLength = 997 // characters
+	MaxQRVersion     = 25  // with error correction level M
+)
+
+var (
+	// ErrPayloadTooLong is returned by Encode if the payload exceeds
+	// MaxPayloadLength characters.
+	ErrPayloadTooLong = errors.New("payload too long")
+
+	// ErrQRVersionTooHigh is returned by Encode if the payload does not fit
+	// into a QR code of version MaxQRVersion.
+	ErrQRVersionTooHigh = errors.New("QR code version too high")
+)
+
 type Bill struct {
 	qrcontents string
+	payloadLen int
+	qrVersion  int
+	qrch       *QRCH    // normalized
+	changes    []Change // made during normalization
+}
+
+// QRCH returns a copy of the normalized QRCH which the bill was encoded from,
+// including the fixed values.
+func (b *Bill) QRCH() *QRCH {
+	clone := *b.qrch
+	clone.AltPmtInf.AltPmt = append([]string(nil), b.qrch.AltPmtInf.AltPmt...)
+	return &clone
+}
+
+// Changes returns the normalization report: all fields which Encode
+// truncated, stripped or reformatted, with their values before and after.
+// With WithStrictValidation, no fields are modified.
+func (b *Bill) Changes() []Change {
+	return append([]Change(nil), b.changes...)
+}
+
+// IsNotification reports whether the bill is a notification, which must not
+// be paid, see QRCH.SetNotification.
+func (b *Bill) IsNotification() bool {
+	return b.qrch.IsNotification()
+}
+
+// PayloadLength returns the length of the payload in characters, which is at
+// most MaxPayloadLength.
+func (b *Bill) PayloadLength() int {
+	return b.payloadLen
+}
+
+// QRVersion returns the version (size) of the Swiss QR Code with error
+// correction level M, which is at most MaxQRVersion.
+func (b *Bill) QRVersion() int {
+	return b.qrVersion
 }
 
 func (b *Bill) EncodeToString() string {
@@ -1,7 +1,11 @@
 package qrbill_test
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stapelberg/qrbill"
 )
@@ -38,23 +42,24 @@ func TestAmountValidation(t *testing.T) {
 		},
 
 		{
+			// more than two decimal places are left for ValidateStrict
 			amount:     "50.000",
-			wantAmount: "50.00",
+			wantAmount: "50.000",
 		},
 
 		{
 			amount:     "50.339",
-			wantAmount: "50.34",
+			wantAmount: "50.339",
 		},
 
 		{
 			amount:     "50.331",
-			wantAmount: "50.33",
+			wantAmount: "50.331",
 		},
 
 		{
 			amount:     "50.-",
-			wantAmount: "0.00", // result of invalid input
+			wantAmount: "50.-", // invalid input is left for ValidateStrict
 		},
 
 		{
@@ -118,3 +123,496 @@ func TestAmountValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeInvalidAmount(t *testing.T) {
+	// Invalid amounts are reported as entered, not as the amount they would
+	// be rounded or defaulted to:
+	for _, amount := range []string{"garbage", "50.339", "0.001", "999999999.995"} {
+		t.Run(amount, func(t *testing.T) {
+			qrch := testQRCH()
+			qrch.CcyAmt.Amt = amount
+			_, err := qrch.Encode()
+			var verr *qrbill.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Encode() = %v, want *ValidationError", err)
+			}
+			want := qrbill.Violation{
+				Field: "CcyAmt.Amt",
+				Rule:  "decimal with two decimal places, e.g. 50.00",
+				Value: amount,
+			}
+			if !reflect.DeepEqual(verr.Violations, []qrbill.Violation{want}) {
+				t.Errorf("Encode() violations = %v, want %v", verr.Violations, want)
+			}
+		})
+	}
+}
+
+func TestValidateStrict(t *testing.T) {
+	qrch := &qrbill.QRCH{
+		CdtrInf: qrbill.QRCHCdtrInf{
+			IBAN: "CH44 3199 9123 0008 8901 2",
+			Cdtr: qrbill.Address{
+				AdrTp:            qrbill.AddressTypeStructured,
+				Name:             strings.Repeat("Legalize it ", 10),
+				StrtNmOrAdrLine1: "Quellenstrasse",
+				BldgNbOrAdrLine2: "25",
+				PstCd:            "8005",
+				TwnNm:            "Zürich",
+				Ctry:             "CH",
+			},
+		},
+		CcyAmt: qrbill.QRCHCcyAmt{
+			Amt: "50.-",
+			Ccy: "CHF",
+		},
+		RmtInf: qrbill.QRCHRmtInf{
+			Tp:  "QRR",
+			Ref: "210000000003139471430009018",
+			AddInf: qrbill.QRCHRmtInfAddInf{
+				Ustrd: "Spende 420",
+			},
+		},
+	}
+	before := *qrch
+
+	err := qrch.ValidateStrict()
+	var verr *qrbill.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ValidateStrict() = %v, want *ValidationError", err)
+	}
+	var got []string
+	for _, v := range verr.Violations {
+		got = append(got, v.Field)
+	}
+	want := []string{
+		"CdtrInf.IBAN",
+		"CdtrInf.Cdtr.Name",
+		"CcyAmt.Amt",
+		"RmtInf.Ref",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateStrict() violations = %v, want fields %v", verr.Violations, want)
+	}
+	if !reflect.DeepEqual(*qrch, before) {
+		t.Errorf("ValidateStrict() modified its input")
+	}
+
+	if _, err := qrch.Encode(qrbill.WithStrictValidation()); !errors.As(err, &verr) {
+		t.Errorf("Encode(WithStrictValidation()) = %v, want *ValidationError", err)
+	}
+
+	// Validate cannot fix the reference check digit, so Encode must fail even
+	// without strict validation. The unparseable amount becomes 0.00, which
+	// is only permitted for notifications:
+	_, err = qrch.Encode()
+	if !errors.As(err, &verr) {
+		t.Fatalf("Encode() = %v, want *ValidationError", err)
+	}
+	got = nil
+	for _, v := range verr.Violations {
+		got = append(got, v.Field)
+	}
+	if want := []string{"CcyAmt.Amt", "RmtInf.Ref"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode() violation fields = %v, want %v", got, want)
+	}
+
+	qrch.CdtrInf.IBAN = "CH4431999123000889012"
+	qrch.CdtrInf.Cdtr.Name = "Legalize it"
+	qrch.CcyAmt.Amt = "50.00"
+	qrch.RmtInf.Ref = "210000000003139471430009017"
+	if err := qrch.ValidateStrict(); err != nil {
+		t.Errorf("ValidateStrict() = %v", err)
+	}
+	if _, err := qrch.Encode(qrbill.WithStrictValidation()); err != nil {
+		t.Errorf("Encode(WithStrictValidation()) = %v", err)
+	}
+}
+
+func TestIBAN(t *testing.T) {
+	for _, tt := range []struct {
+		iban       string
+		wantErr    bool
+		wantQRIBAN bool
+	}{
+		{iban: "CH0209000000870913543"},
+		{iban: "CH93 0076 2011 6238 5295 7"},
+		{iban: "LI21088100002324013AA"},
+		{iban: "CH4431999123000889012", wantQRIBAN: true},
+		{iban: "CH4431999123000889013", wantErr: true},  // check digits
+		{iban: "DE89370400440532013000", wantErr: true}, // country
+		{iban: "CH02090000008709135", wantErr: true},    // length
+		{iban: "", wantErr: true},
+	} {
+		t.Run(tt.iban, func(t *testing.T) {
+			err := qrbill.ValidateIBAN(tt.iban)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("ValidateIBAN(%q) = %v, want error: %v", tt.iban, err, tt.wantErr)
+			}
+			if got, want := qrbill.IsQRIBAN(tt.iban), tt.wantQRIBAN; got != want {
+				t.Errorf("IsQRIBAN(%q) = %v, want %v", tt.iban, got, want)
+			}
+		})
+	}
+}
+
+func TestReferenceTypeConsistency(t *testing.T) {
+	for _, tt := range []struct {
+		iban    string
+		tp      string
+		ref     string
+		wantErr bool
+	}{
+		{iban: "CH4431999123000889012", tp: "QRR", ref: "210000000003139471430009017"},
+		{iban: "CH4431999123000889012", tp: "SCOR", ref: "RF18539007547034", wantErr: true},
+		{iban: "CH4431999123000889012", tp: "NON", wantErr: true},
+		{iban: "CH0209000000870913543", tp: "SCOR", ref: "RF18539007547034"},
+		{iban: "CH0209000000870913543", tp: "NON"},
+		{iban: "CH0209000000870913543", tp: "QRR", ref: "210000000003139471430009017", wantErr: true},
+	} {
+		t.Run(tt.iban+"/"+tt.tp, func(t *testing.T) {
+			qrch := testQRCH()
+			qrch.CdtrInf.IBAN = tt.iban
+			qrch.RmtInf.Tp = tt.tp
+			qrch.RmtInf.Ref = tt.ref
+			_, err := qrch.Encode()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("Encode() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	for _, tt := range []struct {
+		ccy     qrbill.Currency
+		wantErr bool
+	}{
+		{ccy: qrbill.CurrencyCHF},
+		{ccy: qrbill.CurrencyEUR},
+		{ccy: "eur"}, // normalized by Validate
+		{ccy: "USD", wantErr: true},
+		{ccy: "", wantErr: true},
+	} {
+		t.Run(string(tt.ccy), func(t *testing.T) {
+			qrch := testQRCH()
+			qrch.CcyAmt.Ccy = tt.ccy
+			bill, err := qrch.Encode()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("Encode() = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var verr *qrbill.ValidationError
+				if !errors.As(err, &verr) || verr.Violations[0].Field != "CcyAmt.Ccy" {
+					t.Errorf("Encode() = %v, want CcyAmt.Ccy violation", err)
+				}
+				return
+			}
+			txt := bill.EncodeToString()
+			want := strings.ToUpper(string(tt.ccy))
+			if got := strings.Split(txt, "\n")[19]; got != want {
+				t.Errorf("payload currency = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSpecVersion(t *testing.T) {
+	v23 := qrbill.WithSpecVersion(qrbill.SpecVersion23)
+
+	// testQRCH uses a combined address for the ultimate debtor:
+	qrch := testQRCH()
+	if _, err := qrch.Encode(); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	var verr *qrbill.ValidationError
+	if _, err := qrch.Encode(v23); !errors.As(err, &verr) {
+		t.Fatalf("Encode(v2.3) = %v, want *ValidationError", err)
+	}
+	if got, want := verr.Violations[0].Field, "UltmtDbtr.AdrTp"; got != want {
+		t.Errorf("violation field = %q, want %q", got, want)
+	}
+
+	qrch.UltmtDbtr = qrbill.Address{
+		AdrTp:            qrbill.AddressTypeStructured,
+		Name:             "Łukasz Żółć",
+		StrtNmOrAdrLine1: "Stauffacherstrasse",
+		BldgNbOrAdrLine2: "42",
+		PstCd:            "8004",
+		TwnNm:            "Zürich",
+		Ctry:             "CH",
+	}
+	qrch.RmtInf.AddInf.Ustrd = "Spende 420 €"
+
+	// Version 2.1 does not permit the extended character set:
+	if err := qrch.ValidateStrict(); !errors.As(err, &verr) {
+		t.Fatalf("ValidateStrict() = %v, want *ValidationError", err)
+	}
+	if got, want := qrch.Validate().RmtInf.AddInf.Ustrd, "Spende 420 EUR"; got != want {
+		t.Errorf("Validate().RmtInf.AddInf.Ustrd = %q, want %q", got, want)
+	}
+
+	if err := qrch.ValidateStrict(v23); err != nil {
+		t.Errorf("ValidateStrict(v2.3) = %v", err)
+	}
+	validated := qrch.Validate(v23)
+	if got, want := validated.RmtInf.AddInf.Ustrd, qrch.RmtInf.AddInf.Ustrd; got != want {
+		t.Errorf("Validate(v2.3).RmtInf.AddInf.Ustrd = %q, want %q", got, want)
+	}
+	if got, want := validated.UltmtDbtr.Name, qrch.UltmtDbtr.Name; got != want {
+		t.Errorf("Validate(v2.3).UltmtDbtr.Name = %q, want %q", got, want)
+	}
+
+	// Characters outside of the extended character set are still rejected:
+	qrch.UltmtDbtr.Name = "Alice ❤"
+	if err := qrch.ValidateStrict(v23); !errors.As(err, &verr) {
+		t.Fatalf("ValidateStrict(v2.3) = %v, want *ValidationError", err)
+	}
+	if got, want := qrch.Validate(v23).UltmtDbtr.Name, "Alice "; got != want {
+		t.Errorf("Validate(v2.3).UltmtDbtr.Name = %q, want %q", got, want)
+	}
+
+	if _, err := qrch.Encode(qrbill.WithSpecVersion("1.0")); err == nil {
+		t.Errorf("Encode(WithSpecVersion(1.0)) unexpectedly succeeded")
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	qrch := testQRCH()
+	qrch.CdtrInf.Cdtr.Name = strings.Repeat("Müller-Lüdenscheidt AG ", 4)
+	qrch.CdtrInf.Cdtr.TwnNm = strings.Repeat("ü", 36)
+	qrch.RmtInf.AddInf.Ustrd = strings.Repeat("ä", 141)
+
+	validated := qrch.Validate()
+	for _, tt := range []struct {
+		field, value string
+		want         int
+	}{
+		{"CdtrInf.Cdtr.Name", validated.CdtrInf.Cdtr.Name, 70},
+		{"CdtrInf.Cdtr.TwnNm", validated.CdtrInf.Cdtr.TwnNm, 35},
+		{"RmtInf.AddInf.Ustrd", validated.RmtInf.AddInf.Ustrd, 140},
+	} {
+		if !utf8.ValidString(tt.value) {
+			t.Errorf("%s = %q is not valid UTF-8", tt.field, tt.value)
+		}
+		if got := utf8.RuneCountInString(tt.value); got != tt.want {
+			t.Errorf("%s has %d characters, want %d", tt.field, got, tt.want)
+		}
+	}
+	if got, want := validated.CdtrInf.Cdtr.Name, "Müller-Lüdenscheidt AG Müller-Lüdenscheidt AG Müller-Lüdenscheidt AG M"; got != want {
+		t.Errorf("CdtrInf.Cdtr.Name = %q, want %q", got, want)
+	}
+
+	// Lengths are counted in characters, not bytes:
+	qrch = testQRCH()
+	qrch.CdtrInf.Cdtr.Name = strings.Repeat("ü", 70)
+	qrch.RmtInf.AddInf.Ustrd = strings.Repeat("ä", 140)
+	if err := qrch.ValidateStrict(); err != nil {
+		t.Errorf("ValidateStrict() = %v", err)
+	}
+	qrch.CdtrInf.Cdtr.Name += "ü"
+	if err := qrch.ValidateStrict(); err == nil {
+		t.Errorf("ValidateStrict() unexpectedly succeeded for a name of 71 characters")
+	}
+}
+
+func TestAddressValidation(t *testing.T) {
+	structured := qrbill.Address{
+		AdrTp:            qrbill.AddressTypeStructured,
+		Name:             "Legalize it",
+		StrtNmOrAdrLine1: "Quellenstrasse",
+		BldgNbOrAdrLine2: "25",
+		PstCd:            "8005",
+		TwnNm:            "Zürich",
+		Ctry:             "CH",
+	}
+	combined := qrbill.Address{
+		AdrTp:            qrbill.AddressTypeCombined,
+		Name:             "Michael Stapelberg",
+		StrtNmOrAdrLine1: "Stauffacherstr 42",
+		BldgNbOrAdrLine2: "8004 Zürich",
+		Ctry:             "CH",
+	}
+	for _, tt := range []struct {
+		desc       string
+		addr       qrbill.Address
+		modify     func(a *qrbill.Address)
+		wantFields []string
+	}{
+		{
+			desc:   "empty",
+			modify: func(a *qrbill.Address) {},
+		},
+
+		{
+			desc:   "structured",
+			addr:   structured,
+			modify: func(a *qrbill.Address) {},
+		},
+
+		{
+			desc:   "combined",
+			addr:   combined,
+			modify: func(a *qrbill.Address) {},
+		},
+
+		{
+			desc:       "partial",
+			modify:     func(a *qrbill.Address) { a.TwnNm = "Zürich" },
+			wantFields: []string{"AdrTp", "Name", "Ctry"},
+		},
+
+		{
+			desc:       "missing name",
+			addr:       structured,
+			modify:     func(a *qrbill.Address) { a.Name = "" },
+			wantFields: []string{"Name"},
+		},
+
+		{
+			desc: "structured without postal code and town",
+			addr: structured,
+			modify: func(a *qrbill.Address) {
+				a.PstCd = ""
+				a.TwnNm = ""
+			},
+			wantFields: []string{"PstCd", "TwnNm"},
+		},
+
+		{
+			desc:       "structured with long building number",
+			addr:       structured,
+			modify:     func(a *qrbill.Address) { a.BldgNbOrAdrLine2 = strings.Repeat("1", 17) },
+			wantFields: []string{"BldgNbOrAdrLine2"},
+		},
+
+		{
+			desc:   "combined with long address line",
+			addr:   combined,
+			modify: func(a *qrbill.Address) { a.BldgNbOrAdrLine2 = "8004 " + strings.Repeat("Zürich ", 9) },
+		},
+
+		{
+			desc: "combined with postal code and town",
+			addr: combined,
+			modify: func(a *qrbill.Address) {
+				a.PstCd = "8004"
+				a.TwnNm = "Zürich"
+			},
+			wantFields: []string{"PstCd", "TwnNm"},
+		},
+
+		{
+			desc:       "combined without address line 2",
+			addr:       combined,
+			modify:     func(a *qrbill.Address) { a.BldgNbOrAdrLine2 = "" },
+			wantFields: []string{"BldgNbOrAdrLine2"},
+		},
+
+		{
+			desc:       "country prefix",
+			addr:       structured,
+			modify:     func(a *qrbill.Address) { a.PstCd = "CH-8005" },
+			wantFields: []string{"PstCd"},
+		},
+
+		{
+			desc:       "invalid country",
+			addr:       structured,
+			modify:     func(a *qrbill.Address) { a.Ctry = "XY" },
+			wantFields: []string{"Ctry"},
+		},
+
+		{
+			desc:       "invalid address type",
+			addr:       structured,
+			modify:     func(a *qrbill.Address) { a.AdrTp = "X" },
+			wantFields: []string{"AdrTp"},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			addr := tt.addr
+			tt.modify(&addr)
+			var gotFields []string
+			var verr *qrbill.ValidationError
+			if err := addr.ValidateStrict(); errors.As(err, &verr) {
+				for _, v := range verr.Violations {
+					gotFields = append(gotFields, v.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("ValidateStrict() = %v", err)
+			}
+			if !reflect.DeepEqual(gotFields, tt.wantFields) {
+				t.Errorf("ValidateStrict() violations = %v, want fields %v", verr, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestAddressNormalization(t *testing.T) {
+	addr := qrbill.Address{
+		AdrTp:            qrbill.AddressTypeStructured,
+		Name:             "Legalize it",
+		StrtNmOrAdrLine1: "Quellenstrasse",
+		BldgNbOrAdrLine2: "25",
+		PstCd:            "CH-8005",
+		TwnNm:            "Zürich",
+		Ctry:             "ch",
+	}
+	validated := addr.Validate()
+	if got, want := validated.PstCd, "8005"; got != want {
+		t.Errorf("PstCd = %q, want %q", got, want)
+	}
+	if got, want := validated.Ctry, "CH"; got != want {
+		t.Errorf("Ctry = %q, want %q", got, want)
+	}
+	if err := validated.ValidateStrict(); err != nil {
+		t.Errorf("ValidateStrict() = %v", err)
+	}
+
+	if name, ok := qrbill.CountryName("LI"); !ok || name != "Liechtenstein" {
+		t.Errorf(`CountryName("LI") = %q, %v, want "Liechtenstein", true`, name, ok)
+	}
+}
+
+func TestPayloadLimits(t *testing.T) {
+	bill, err := testQRCH().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bill.PayloadLength(), utf8.RuneCountInString(bill.EncodeToString()); got != want {
+		t.Errorf("PayloadLength() = %d, want %d", got, want)
+	}
+	if got := bill.QRVersion(); got < 1 || got > qrbill.MaxQRVersion {
+		t.Errorf("QRVersion() = %d, want 1..%d", got, qrbill.MaxQRVersion)
+	}
+
+	maxedOut := func(s string) *qrbill.QRCH {
+		addr := qrbill.Address{
+			AdrTp:            qrbill.AddressTypeCombined,
+			Name:             strings.Repeat(s, 70),
+			StrtNmOrAdrLine1: strings.Repeat(s, 70),
+			BldgNbOrAdrLine2: strings.Repeat(s, 70),
+			Ctry:             "CH",
+		}
+		qrch := testQRCH()
+		qrch.CdtrInf.Cdtr = addr
+		qrch.UltmtCdtr = addr
+		qrch.UltmtDbtr = addr
+		qrch.RmtInf.AddInf.Ustrd = strings.Repeat(s, 140)
+		qrch.AltPmtInf.AltPmt = []string{strings.Repeat(s, 100), strings.Repeat(s, 100)}
+		return qrch
+	}
+
+	if _, err := maxedOut("x").Encode(); !errors.Is(err, qrbill.ErrPayloadTooLong) {
+		t.Errorf("Encode() = %v, want ErrPayloadTooLong", err)
+	}
+
+	// Multi-byte characters need more space in the QR code:
+	qrch := maxedOut("ä")
+	qrch.UltmtCdtr = qrbill.Address{}
+	qrch.AltPmtInf.AltPmt = nil
+	if _, err := qrch.Encode(); !errors.Is(err, qrbill.ErrQRVersionTooHigh) {
+		t.Errorf("Encode() = %v, want ErrQRVersionTooHigh", err)
+	}
+}
@@ -146,7 +146,8 @@ func renderResultPDF(code *encoder.QRCode, width, height, quietZone int) ([]byte
 
 	kids := []pdf.Object{
 		&pdf.Page{
-			Common: pdf.Common{ObjectName: "page0"},
+			Common:   pdf.Common{ObjectName: "page0"},
+			MediaBox: [4]float64{0, 0, 152, 152},
 			Resources: []pdf.Object{
 				&Image{
 					Common: pdf.Common{
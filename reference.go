@@ -0,0 +1,204 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference types, as used in QRCHRmtInf.Tp.
+const (
+	// ReferenceTypeQRR is the QR reference, which must be used with a
+	// QR-IBAN.
+	ReferenceTypeQRR = "QRR"
+
+	// ReferenceTypeSCOR is the Creditor Reference (ISO 11649).
+	ReferenceTypeSCOR = "SCOR"
+
+	// ReferenceTypeNON indicates that no reference is used.
+	ReferenceTypeNON = "NON"
+)
+
+// qrReferenceLen is the length of a QR reference, including the check digit.
+const qrReferenceLen = 27
+
+// mod10Table is the table used for calculating the check digit of QR
+// references (modulo 10, recursive), see also Appendix B of the Swiss
+// Implementation Guidelines QR-bill.
+var mod10Table = [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+
+// qrReferenceCheckDigit returns the check digit for the specified string of
+// decimal digits.
+func qrReferenceCheckDigit(digits string) int {
+	carry := 0
+	for _, r := range digits {
+		carry = mod10Table[(carry+int(r-'0'))%10]
+	}
+	return (10 - carry) % 10
+}
+
+// stripSpaces removes all whitespace from s, so that references can be passed
+// in their human-readable (grouped) form.
+func stripSpaces(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// QRReference returns the QR reference (QRR) for the specified reference
+// number, e.g. a customer number followed by an invoice number. The number is
+// padded with leading zeros to 26 digits and completed with the check digit.
+func QRReference(number string) (string, error) {
+	number = stripSpaces(number)
+	if number == "" {
+		return "", fmt.Errorf("QR reference number must not be empty")
+	}
+	if !isDigits(number) {
+		return "", fmt.Errorf("QR reference number %q must only contain digits", number)
+	}
+	if len(number) > qrReferenceLen-1 {
+		return "", fmt.Errorf("QR reference number %q is too long: got %d digits, want at most %d", number, len(number), qrReferenceLen-1)
+	}
+	padded := strings.Repeat("0", qrReferenceLen-1-len(number)) + number
+	if strings.Trim(padded, "0") == "" {
+		// As per the Implementation Guidelines, a QR reference consisting only
+		// of zeros is not permitted.
+		return "", fmt.Errorf("QR reference number must not be zero")
+	}
+	return padded + fmt.Sprint(qrReferenceCheckDigit(padded)), nil
+}
+
+// ValidateQRReference returns an error if ref is not a valid QR reference,
+// i.e. not 27 digits long or with a wrong check digit. Whitespace is ignored.
+func ValidateQRReference(ref string) error {
+	ref = stripSpaces(ref)
+	if len(ref) != qrReferenceLen {
+		return fmt.Errorf("QR reference %q has invalid length: got %d, want %d", ref, len(ref), qrReferenceLen)
+	}
+	if !isDigits(ref) {
+		return fmt.Errorf("QR reference %q must only contain digits", ref)
+	}
+	if strings.Trim(ref, "0") == "" {
+		return fmt.Errorf("QR reference must not be zero")
+	}
+	body, check := ref[:qrReferenceLen-1], int(ref[qrReferenceLen-1]-'0')
+	if want := qrReferenceCheckDigit(body); check != want {
+		return fmt.Errorf("QR reference %q has invalid check digit: got %d, want %d", ref, check, want)
+	}
+	return nil
+}
+
+// Creditor Reference (ISO 11649) limits: “RF”, two check digits and up to 21
+// alphanumeric characters.
+const (
+	creditorReferenceMaxLen     = 25
+	creditorReferenceMaxBodyLen = creditorReferenceMaxLen - 4
+)
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// mod97 returns the remainder of dividing the number represented by the
+// alphanumeric string s by 97, after replacing each letter with two digits
+// (A = 10, B = 11, …, Z = 35), as per ISO 7064 MOD 97-10.
+//
+// The caller must ensure s only contains alphanumeric characters.
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range strings.ToUpper(s) {
+		if r >= 'A' && r <= 'Z' {
+			v := int(r-'A') + 10
+			remainder = (remainder*100 + v) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	return remainder
+}
+
+// CreditorReference returns the Creditor Reference (SCOR, ISO 11649) for the
+// specified reference, which consists of up to 21 alphanumeric characters.
+// The result consists of “RF”, two check digits and the upper-cased
+// reference.
+func CreditorReference(ref string) (string, error) {
+	ref = strings.ToUpper(stripSpaces(ref))
+	if ref == "" {
+		return "", fmt.Errorf("creditor reference must not be empty")
+	}
+	if !isAlphanumeric(ref) {
+		return "", fmt.Errorf("creditor reference %q must only contain alphanumeric characters", ref)
+	}
+	if len(ref) > creditorReferenceMaxBodyLen {
+		return "", fmt.Errorf("creditor reference %q is too long: got %d characters, want at most %d", ref, len(ref), creditorReferenceMaxBodyLen)
+	}
+	check := 98 - mod97(ref+"RF00")
+	return fmt.Sprintf("RF%02d%s", check, ref), nil
+}
+
+// ValidateCreditorReference returns an error if ref is not a valid Creditor
+// Reference (SCOR, ISO 11649), i.e. does not start with “RF”, is too long or
+// has wrong check digits. Whitespace is ignored.
+func ValidateCreditorReference(ref string) error {
+	ref = strings.ToUpper(stripSpaces(ref))
+	if len(ref) < 5 || len(ref) > creditorReferenceMaxLen {
+		return fmt.Errorf("creditor reference %q has invalid length: got %d, want 5 to %d", ref, len(ref), creditorReferenceMaxLen)
+	}
+	if !strings.HasPrefix(ref, "RF") {
+		return fmt.Errorf("creditor reference %q must start with RF", ref)
+	}
+	if !isDigits(ref[2:4]) {
+		return fmt.Errorf("creditor reference %q has non-numeric check digits", ref)
+	}
+	if !isAlphanumeric(ref[4:]) {
+		return fmt.Errorf("creditor reference %q must only contain alphanumeric characters", ref)
+	}
+	if mod97(ref[4:]+ref[:4]) != 1 {
+		return fmt.Errorf("creditor reference %q has invalid check digits", ref)
+	}
+	return nil
+}
+
+// ValidateReference returns an error if the reference does not match the
+// reference type: QRR requires a valid QR reference, SCOR requires a valid
+// Creditor Reference and NON requires an empty reference.
+func (r QRCHRmtInf) ValidateReference() error {
+	switch r.Tp {
+	case ReferenceTypeQRR:
+		return ValidateQRReference(r.Ref)
+	case ReferenceTypeSCOR:
+		return ValidateCreditorReference(r.Ref)
+	case ReferenceTypeNON:
+		if r.Ref != "" {
+			return fmt.Errorf("reference type %s must not be used with a reference, got %q", r.Tp, r.Ref)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown reference type %q, want one of %s, %s or %s", r.Tp, ReferenceTypeQRR, ReferenceTypeSCOR, ReferenceTypeNON)
+	}
+}
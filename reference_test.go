@@ -0,0 +1,188 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func TestQRReference(t *testing.T) {
+	for _, tt := range []struct {
+		number  string
+		wantRef string
+		wantErr bool
+	}{
+		{
+			// example from the Implementation Guidelines
+			number:  "21000000000313947143000901",
+			wantRef: "210000000003139471430009017",
+		},
+
+		{
+			number:  "00 00000 00000 00000 00000 120",
+			wantRef: "000000000000000000000001205",
+		},
+
+		{
+			number:  "4711",
+			wantRef: "000000000000000000000047119",
+		},
+
+		{
+			number:  "",
+			wantErr: true,
+		},
+
+		{
+			number:  "0",
+			wantErr: true,
+		},
+
+		{
+			number:  "12a",
+			wantErr: true,
+		},
+
+		{
+			// 27 digits, one too many
+			number:  "210000000003139471430009017",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.number, func(t *testing.T) {
+			ref, err := qrbill.QRReference(tt.number)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("QRReference(%q) = %v, want error: %v", tt.number, err, tt.wantErr)
+			}
+			if got, want := ref, tt.wantRef; got != want {
+				t.Errorf("QRReference(%q) = %q, want %q", tt.number, got, want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if err := qrbill.ValidateQRReference(ref); err != nil {
+				t.Errorf("ValidateQRReference(%q) = %v", ref, err)
+			}
+		})
+	}
+}
+
+func TestValidateQRReference(t *testing.T) {
+	for _, tt := range []struct {
+		ref     string
+		wantErr bool
+	}{
+		{ref: "210000000003139471430009017"},
+		{ref: "21 00000 00003 13947 14300 09017"},
+		{ref: "210000000003139471430009018", wantErr: true}, // check digit
+		{ref: "210000000003139471430009071", wantErr: true}, // transposition
+		{ref: "21000000000313947143000901", wantErr: true},  // too short
+		{ref: "21000000000313947143000901A", wantErr: true}, // not numeric
+		{ref: "000000000000000000000000000", wantErr: true}, // zero
+		{ref: "", wantErr: true},
+	} {
+		t.Run(tt.ref, func(t *testing.T) {
+			err := qrbill.ValidateQRReference(tt.ref)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("ValidateQRReference(%q) = %v, want error: %v", tt.ref, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreditorReference(t *testing.T) {
+	for _, tt := range []struct {
+		ref     string
+		wantRef string
+		wantErr bool
+	}{
+		{
+			// example from ISO 11649
+			ref:     "539007547034",
+			wantRef: "RF18539007547034",
+		},
+
+		{
+			ref:     "invoice 4711",
+			wantRef: "RF88INVOICE4711",
+		},
+
+		{
+			ref:     "",
+			wantErr: true,
+		},
+
+		{
+			ref:     "invoice-4711",
+			wantErr: true,
+		},
+
+		{
+			// 22 characters, one too many
+			ref:     "1234567890123456789012",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := qrbill.CreditorReference(tt.ref)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("CreditorReference(%q) = %v, want error: %v", tt.ref, err, tt.wantErr)
+			}
+			if got, want := ref, tt.wantRef; got != want {
+				t.Errorf("CreditorReference(%q) = %q, want %q", tt.ref, got, want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if err := qrbill.ValidateCreditorReference(ref); err != nil {
+				t.Errorf("ValidateCreditorReference(%q) = %v", ref, err)
+			}
+		})
+	}
+}
+
+func TestValidateCreditorReference(t *testing.T) {
+	for _, tt := range []struct {
+		ref     string
+		wantErr bool
+	}{
+		{ref: "RF18539007547034"},
+		{ref: "RF18 5390 0754 7034"},
+		{ref: "rf18539007547034"},
+		{ref: "RF19539007547034", wantErr: true},           // check digits
+		{ref: "RF18539007547043", wantErr: true},           // transposition
+		{ref: "XX18539007547034", wantErr: true},           // prefix
+		{ref: "RF1", wantErr: true},                        // too short
+		{ref: "RF181234567890123456789012", wantErr: true}, // too long
+		{ref: "", wantErr: true},
+	} {
+		t.Run(tt.ref, func(t *testing.T) {
+			err := qrbill.ValidateCreditorReference(tt.ref)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("ValidateCreditorReference(%q) = %v, want error: %v", tt.ref, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReference(t *testing.T) {
+	for _, tt := range []struct {
+		rmtInf  qrbill.QRCHRmtInf
+		wantErr bool
+	}{
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "QRR", Ref: "210000000003139471430009017"}},
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "SCOR", Ref: "RF18539007547034"}},
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "NON"}},
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "QRR", Ref: "RF18539007547034"}, wantErr: true},
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "SCOR", Ref: "210000000003139471430009017"}, wantErr: true},
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "NON", Ref: "RF18539007547034"}, wantErr: true},
+		{rmtInf: qrbill.QRCHRmtInf{Tp: "ESR", Ref: "210000000003139471430009017"}, wantErr: true},
+	} {
+		t.Run(tt.rmtInf.Tp+"/"+tt.rmtInf.Ref, func(t *testing.T) {
+			err := tt.rmtInf.ValidateReference()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("ValidateReference() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
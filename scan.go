@@ -0,0 +1,139 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/common"
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/detector"
+)
+
+// Errors returned by DecodeImage. Use errors.Is to check for them.
+var (
+	ErrNoQRCode     = errors.New("no QR code found")
+	ErrNoSwissCross = errors.New("QR code has no Swiss cross")
+)
+
+func qrDecodeHints() map[gozxing.DecodeHintType]interface{} {
+	return map[gozxing.DecodeHintType]interface{}{
+		// Spend more time on locating the code, which helps with photos:
+		gozxing.DecodeHintType_TRY_HARDER: true,
+
+		// Section 4.2.1: Character set:
+		// UTF-8 should be used for encoding
+		gozxing.DecodeHintType_CHARACTER_SET: "UTF-8",
+	}
+}
+
+// hasSwissCross reports whether the QR code found by the detector in matrix
+// contains the Swiss cross in its center.
+func hasSwissCross(matrix *gozxing.BitMatrix, detected *common.DetectorResult) bool {
+	points := detected.GetPoints()
+	if len(points) < 3 {
+		return false
+	}
+	var alignment *detector.AlignmentPattern
+	if len(points) > 3 {
+		alignment, _ = points[3].(*detector.AlignmentPattern)
+	}
+	dim := detected.GetBits().GetWidth()
+	// The transform maps module coordinates to image coordinates, taking
+	// rotation and perspective into account:
+	transform := detector.Detector_createTransform(points[1], points[2], points[0], alignment, dim)
+
+	// As per section 6.4.2.1, the Swiss cross measures 7x7 mm on a
+	// 46x46 mm QR code.
+	crossModules := float64(dim) * swissCrossEdgeSideMm / 46
+	center := float64(dim) / 2
+	// isBlack returns the color at the specified position relative to the
+	// center of the code, in fractions of the Swiss cross edge length.
+	isBlack := func(u, v float64) bool {
+		p := []float64{center + u*crossModules, center + v*crossModules}
+		transform.TransformPoints(p)
+		x, y := int(p[0]), int(p[1])
+		if x < 0 || y < 0 || x >= matrix.GetWidth() || y >= matrix.GetHeight() {
+			return false
+		}
+		return matrix.Get(x, y)
+	}
+
+	// Within the cross (see swisscross.svg), the white arms are 0.17 wide and
+	// 0.57 long, the black square is 0.86 wide. Sample along the arms and in
+	// the black square between the arms, and allow for some noise:
+	var white, black, total int
+	for t := -0.24; t <= 0.24; t += 0.04 {
+		total += 2
+		if !isBlack(t, 0) {
+			white++
+		}
+		if !isBlack(0, t) {
+			white++
+		}
+	}
+	corners := [][2]float64{
+		{0.27, 0.27},
+		{0.35, 0.18},
+		{0.18, 0.35},
+	}
+	for _, c := range corners {
+		for _, sign := range [][2]float64{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}} {
+			if isBlack(sign[0]*c[0], sign[1]*c[1]) {
+				black++
+			}
+		}
+	}
+	return float64(white)/float64(total) >= 0.8 &&
+		float64(black)/float64(4*len(corners)) >= 0.8
+}
+
+// DecodeImage locates the Swiss QR code in img (e.g. a scan or photo of a
+// QR-bill), decodes it and parses its payload using Parse.
+//
+// The code may be rotated or photographed at an angle. DecodeImage returns
+// ErrNoQRCode if no QR code could be read, a *ParseError if the QR code does
+// not contain a Swiss QR code payload, and ErrNoSwissCross if the QR code
+// lacks the Swiss cross.
+func DecodeImage(img image.Image) (*QRCH, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := bmp.GetBlackMatrix()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoQRCode, err)
+	}
+	hints := qrDecodeHints()
+	detected, err := detector.NewDetector(matrix).Detect(hints)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoQRCode, err)
+	}
+	decoded, err := decoder.NewDecoder().Decode(detected.GetBits(), hints)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoQRCode, err)
+	}
+	qrch, err := Parse(decoded.GetText())
+	if err != nil {
+		return nil, err
+	}
+	if !hasSwissCross(matrix, detected) {
+		return nil, ErrNoSwissCross
+	}
+	return qrch, nil
+}
@@ -0,0 +1,133 @@
+package qrbill_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stapelberg/qrbill"
+)
+
+// transform returns a white image of the same size as img, onto which img is
+// drawn rotated by deg degrees and sheared horizontally by shear, around the
+// image center. This approximates a photo taken at an angle.
+func transform(img image.Image, deg, shear float64) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(b)
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			// Map the destination pixel back onto the source image:
+			dx, dy := float64(x)-cx, float64(y)-cy
+			sx := cos*dx + sin*dy
+			sy := -sin*dx + cos*dy
+			sx -= shear * sy
+			p := image.Pt(int(sx+cx), int(sy+cy))
+			if !p.In(b) {
+				out.Set(x, y, color.White)
+				continue
+			}
+			out.Set(x, y, img.At(p.X, p.Y))
+		}
+	}
+	return out
+}
+
+// withMargin returns img centered on a white canvas with the specified margin.
+func withMargin(img image.Image, margin int) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx()+2*margin, b.Dy()+2*margin))
+	for y := 0; y < out.Bounds().Dy(); y++ {
+		for x := 0; x < out.Bounds().Dx(); x++ {
+			out.Set(x, y, color.White)
+		}
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.Set(x-b.Min.X+margin, y-b.Min.Y+margin, img.At(x, y))
+		}
+	}
+	return out
+}
+
+func TestDecodeImage(t *testing.T) {
+	qrch := testQRCH()
+	bill, err := qrch.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := bill.EncodeToImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img = withMargin(img, 300)
+
+	for _, tt := range []struct {
+		name  string
+		img   image.Image
+		deg   float64
+		shear float64
+	}{
+		{name: "Upright"},
+		{name: "Rotated90", deg: 90},
+		{name: "Rotated180", deg: 180},
+		{name: "Rotated17", deg: 17},
+		{name: "Skewed", deg: -8, shear: 0.15},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qrbill.DecodeImage(transform(img, tt.deg, tt.shear))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := qrch.Validate(); !reflect.DeepEqual(got, want) {
+				t.Errorf("DecodeImage() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeImageErrors(t *testing.T) {
+	bill, err := testQRCH().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(contents string) image.Image {
+		matrix, err := qrcode.NewQRCodeWriter().Encode(contents, gozxing.BarcodeFormat_QR_CODE, 1265, 1265, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return matrix
+	}
+
+	blank := image.NewGray(image.Rect(0, 0, 500, 500))
+	for idx := range blank.Pix {
+		blank.Pix[idx] = 0xff
+	}
+
+	t.Run("NoQRCode", func(t *testing.T) {
+		if _, err := qrbill.DecodeImage(blank); !errors.Is(err, qrbill.ErrNoQRCode) {
+			t.Errorf("DecodeImage() = %v, want %v", err, qrbill.ErrNoQRCode)
+		}
+	})
+
+	t.Run("NoSwissCross", func(t *testing.T) {
+		img := encode(bill.EncodeToString())
+		if _, err := qrbill.DecodeImage(img); !errors.Is(err, qrbill.ErrNoSwissCross) {
+			t.Errorf("DecodeImage() = %v, want %v", err, qrbill.ErrNoSwissCross)
+		}
+	})
+
+	t.Run("NotSPC", func(t *testing.T) {
+		img := encode("https://github.com/stapelberg/qrbill")
+		if _, err := qrbill.DecodeImage(img); !errors.Is(err, qrbill.ErrInvalidHeader) {
+			t.Errorf("DecodeImage() = %v, want %v", err, qrbill.ErrInvalidHeader)
+		}
+	})
+}
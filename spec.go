@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import "strings"
+
+// SpecVersion is the version of the Implementation Guidelines whose rules are
+// applied by Validate, ValidateStrict and Encode. Both versions produce the
+// same Swiss QR Code header version (0200).
+type SpecVersion string
+
+const (
+	// SpecVersion21 applies the rules of version 2.1, which permit combined
+	// addresses (type K). This is the default.
+	SpecVersion21 SpecVersion = "2.1"
+
+	// SpecVersion23 applies the rules of version 2.3, which are mandatory
+	// from November 2025: only structured addresses (type S) are permitted,
+	// and all text fields may use the extended character set.
+	SpecVersion23 SpecVersion = "2.3"
+)
+
+// WithSpecVersion selects the version of the Implementation Guidelines to
+// validate against. The default is SpecVersion21.
+func WithSpecVersion(v SpecVersion) Option {
+	return func(o *options) {
+		o.version = v
+	}
+}
+
+// extendedChar reports whether r is part of the extended character set of
+// version 2.3 (section 4.1.1): Basic Latin, Latin-1 Supplement, Latin
+// Extended-A, the characters Ș ș Ț ț and the euro sign €.
+func extendedChar(r rune) bool {
+	switch {
+	case r >= 0x20 && r <= 0x7E: // Basic Latin
+		return true
+	case r >= 0xA0 && r <= 0x17F: // Latin-1 Supplement and Latin Extended-A
+		return true
+	case r >= 0x218 && r <= 0x21B: // Ș ș Ț ț
+		return true
+	case r == '€':
+		return true
+	}
+	return false
+}
+
+// filterChars returns s without the characters which are not permitted in v.
+func (v SpecVersion) filterChars(s string) string {
+	if v == SpecVersion23 {
+		return strings.Map(func(r rune) rune {
+			if !extendedChar(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	return strings.Join(ustrdRe.FindAllString(s, -1), "")
+}
+
+// permittedChars reports whether all characters of s are permitted in v.
+func (v SpecVersion) permittedChars(s string) bool {
+	return v.filterChars(s) == s
+}
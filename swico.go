@@ -0,0 +1,284 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Swico S1 syntax for the bill information (StrdBkgInf) is defined in the
+// “Syntax definition of the bill information for QR-bills” by Swico, which is
+// referenced by the Implementation Guidelines (Annex E).
+
+// swicoS1Prefix introduces bill information in Swico S1 syntax.
+const swicoS1Prefix = "//S1"
+
+// swicoDateFormat is the date format used in Swico S1 syntax (YYMMDD).
+const swicoDateFormat = "060102"
+
+// Swico S1 tags.
+const (
+	swicoTagInvoiceNumber     = 10
+	swicoTagInvoiceDate       = 11
+	swicoTagCustomerReference = 20
+	swicoTagVATNumber         = 30
+	swicoTagVATDate           = 31
+	swicoTagVATDetails        = 32
+	swicoTagImportTax         = 33
+	swicoTagConditions        = 40
+)
+
+// VATRate is a VAT rate with the amount it applies to.
+type VATRate struct {
+	Rate   string // Rate in percent, e.g. 8.1
+	Amount string // Net amount (VAT details) or tax amount (import tax), e.g. 1000.00
+}
+
+// PaymentCondition is a discount granted when paying within a number of days.
+// A condition with Discount 0 specifies the payment term.
+type PaymentCondition struct {
+	Discount string // Discount in percent, e.g. 2
+	Days     int    // Number of days after the invoice date
+}
+
+// SwicoS1 is the bill information (StrdBkgInf) in Swico S1 syntax, which
+// accounting software can use to automatically book an invoice. All fields
+// are optional.
+type SwicoS1 struct {
+	InvoiceNumber     string    // Tag 10
+	InvoiceDate       time.Time // Tag 11
+	CustomerReference string    // Tag 20
+	VATNumber         string    // Tag 30, UID number without CHE prefix and separators, e.g. 106017086
+	VATDate           time.Time // Tag 31, date of service (or start of the service period)
+	VATDateEnd        time.Time // Tag 31, end of the service period (optional)
+
+	// VATDetails (tag 32) contains the VAT rates with the net amounts they
+	// apply to. A single rate without amount applies to the whole invoice.
+	VATDetails []VATRate
+
+	ImportTax  []VATRate          // Tag 33, VAT rates with the tax amounts
+	Conditions []PaymentCondition // Tag 40
+}
+
+// swicoDecimalRe matches decimal numbers as used for rates and amounts.
+var swicoDecimalRe = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// swicoEscape escapes the characters \ and / in a Swico S1 value.
+func swicoEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(s)
+}
+
+func encodeVATRates(rates []VATRate, single bool) string {
+	if single && len(rates) == 1 && rates[0].Amount == "" {
+		return rates[0].Rate
+	}
+	parts := make([]string, len(rates))
+	for idx, r := range rates {
+		parts[idx] = r.Rate + ":" + r.Amount
+	}
+	return strings.Join(parts, ";")
+}
+
+// String encodes s in Swico S1 syntax, e.g. //S1/10/10201409/11/190512/…
+func (s *SwicoS1) String() string {
+	var b strings.Builder
+	b.WriteString(swicoS1Prefix)
+	add := func(tag int, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "/%d/%s", tag, value)
+	}
+	add(swicoTagInvoiceNumber, swicoEscape(s.InvoiceNumber))
+	if !s.InvoiceDate.IsZero() {
+		add(swicoTagInvoiceDate, s.InvoiceDate.Format(swicoDateFormat))
+	}
+	add(swicoTagCustomerReference, swicoEscape(s.CustomerReference))
+	add(swicoTagVATNumber, swicoEscape(s.VATNumber))
+	if !s.VATDate.IsZero() {
+		date := s.VATDate.Format(swicoDateFormat)
+		if !s.VATDateEnd.IsZero() {
+			date += s.VATDateEnd.Format(swicoDateFormat)
+		}
+		add(swicoTagVATDate, date)
+	}
+	add(swicoTagVATDetails, encodeVATRates(s.VATDetails, true))
+	add(swicoTagImportTax, encodeVATRates(s.ImportTax, false))
+	conditions := make([]string, len(s.Conditions))
+	for idx, c := range s.Conditions {
+		conditions[idx] = c.Discount + ":" + strconv.Itoa(c.Days)
+	}
+	add(swicoTagConditions, strings.Join(conditions, ";"))
+	return b.String()
+}
+
+// Validate returns an error if s cannot be encoded in valid Swico S1 syntax.
+func (s *SwicoS1) Validate() error {
+	if v := s.VATNumber; v != "" && !isDigits(v) {
+		return fmt.Errorf("VAT number %q must only contain digits (without CHE prefix)", v)
+	}
+	if s.VATDate.IsZero() && !s.VATDateEnd.IsZero() {
+		return fmt.Errorf("VAT date end set without VAT date")
+	}
+	if !s.VATDateEnd.IsZero() && s.VATDateEnd.Before(s.VATDate) {
+		return fmt.Errorf("VAT date end %s before VAT date %s", s.VATDateEnd.Format(time.DateOnly), s.VATDate.Format(time.DateOnly))
+	}
+	for idx, r := range s.VATDetails {
+		if !swicoDecimalRe.MatchString(r.Rate) {
+			return fmt.Errorf("VAT details: invalid rate %q", r.Rate)
+		}
+		if r.Amount == "" && len(s.VATDetails) == 1 {
+			continue // rate applies to the whole invoice
+		}
+		if !swicoDecimalRe.MatchString(r.Amount) {
+			return fmt.Errorf("VAT details: invalid amount %q for entry %d", r.Amount, idx)
+		}
+	}
+	for _, r := range s.ImportTax {
+		if !swicoDecimalRe.MatchString(r.Rate) || !swicoDecimalRe.MatchString(r.Amount) {
+			return fmt.Errorf("import tax: invalid rate/amount %q:%q", r.Rate, r.Amount)
+		}
+	}
+	for _, c := range s.Conditions {
+		if !swicoDecimalRe.MatchString(c.Discount) || c.Days < 0 {
+			return fmt.Errorf("conditions: invalid discount/days %q:%d", c.Discount, c.Days)
+		}
+	}
+	return nil
+}
+
+// splitSwico splits s at unescaped slashes and unescapes the parts.
+func splitSwico(s string) ([]string, error) {
+	var (
+		parts   []string
+		current strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("dangling escape character at the end")
+			}
+			i++
+			current.WriteByte(s[i])
+		case '/':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(parts, current.String()), nil
+}
+
+func parseVATRates(value string, single bool) ([]VATRate, error) {
+	var rates []VATRate
+	for _, entry := range strings.Split(value, ";") {
+		rate, amount, found := strings.Cut(entry, ":")
+		if !found && !(single && !strings.Contains(value, ";")) {
+			return nil, fmt.Errorf("invalid entry %q, want rate:amount", entry)
+		}
+		rates = append(rates, VATRate{Rate: rate, Amount: amount})
+	}
+	return rates, nil
+}
+
+func parseSwicoDate(value string) (time.Time, error) {
+	return time.Parse(swicoDateFormat, value)
+}
+
+// ParseSwicoS1 parses bill information in Swico S1 syntax. Unknown tags are
+// ignored for forward compatibility.
+func ParseSwicoS1(s string) (*SwicoS1, error) {
+	if !strings.HasPrefix(s, swicoS1Prefix+"/") {
+		return nil, fmt.Errorf("bill information %q does not start with %s/", s, swicoS1Prefix)
+	}
+	parts, err := splitSwico(strings.TrimPrefix(s, swicoS1Prefix+"/"))
+	if err != nil {
+		return nil, fmt.Errorf("bill information %q: %v", s, err)
+	}
+	if len(parts)%2 != 0 {
+		return nil, fmt.Errorf("bill information %q: tag %q without value", s, parts[len(parts)-1])
+	}
+	result := &SwicoS1{}
+	lastTag := 0
+	for i := 0; i < len(parts); i += 2 {
+		tag, err := strconv.Atoi(parts[i])
+		if err != nil || !isDigits(parts[i]) {
+			return nil, fmt.Errorf("bill information %q: invalid tag %q", s, parts[i])
+		}
+		if tag <= lastTag {
+			return nil, fmt.Errorf("bill information %q: tag %d out of order or repeated", s, tag)
+		}
+		lastTag = tag
+		value := parts[i+1]
+		switch tag {
+		case swicoTagInvoiceNumber:
+			result.InvoiceNumber = value
+		case swicoTagInvoiceDate:
+			result.InvoiceDate, err = parseSwicoDate(value)
+		case swicoTagCustomerReference:
+			result.CustomerReference = value
+		case swicoTagVATNumber:
+			result.VATNumber = value
+		case swicoTagVATDate:
+			switch len(value) {
+			case len(swicoDateFormat):
+				result.VATDate, err = parseSwicoDate(value)
+			case 2 * len(swicoDateFormat):
+				result.VATDate, err = parseSwicoDate(value[:len(swicoDateFormat)])
+				if err == nil {
+					result.VATDateEnd, err = parseSwicoDate(value[len(swicoDateFormat):])
+				}
+			default:
+				err = fmt.Errorf("invalid date %q, want YYMMDD or YYMMDDYYMMDD", value)
+			}
+		case swicoTagVATDetails:
+			result.VATDetails, err = parseVATRates(value, true)
+		case swicoTagImportTax:
+			result.ImportTax, err = parseVATRates(value, false)
+		case swicoTagConditions:
+			for _, entry := range strings.Split(value, ";") {
+				discount, days, found := strings.Cut(entry, ":")
+				if !found {
+					err = fmt.Errorf("invalid condition %q, want discount:days", entry)
+					break
+				}
+				var c PaymentCondition
+				c.Discount = discount
+				if c.Days, err = strconv.Atoi(days); err != nil {
+					break
+				}
+				result.Conditions = append(result.Conditions, c)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bill information %q: tag %d: %v", s, tag, err)
+		}
+	}
+	if err := result.Validate(); err != nil {
+		return nil, fmt.Errorf("bill information %q: %v", s, err)
+	}
+	return result, nil
+}
+
+// SwicoS1 parses the bill information (StrdBkgInf) in Swico S1 syntax.
+func (a QRCHRmtInfAddInf) SwicoS1() (*SwicoS1, error) {
+	return ParseSwicoS1(a.StrdBkgInf)
+}
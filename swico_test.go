@@ -0,0 +1,150 @@
+package qrbill_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stapelberg/qrbill"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSwicoS1(t *testing.T) {
+	for _, tt := range []struct {
+		encoded string
+		s1      qrbill.SwicoS1
+	}{
+		{
+			// examples from the Swico S1 syntax definition
+			encoded: "//S1/10/10201409/11/190512/20/1400.000-53/30/106017086/31/180508/32/7.7/40/2:10;0:30",
+			s1: qrbill.SwicoS1{
+				InvoiceNumber:     "10201409",
+				InvoiceDate:       date(2019, time.May, 12),
+				CustomerReference: "1400.000-53",
+				VATNumber:         "106017086",
+				VATDate:           date(2018, time.May, 8),
+				VATDetails:        []qrbill.VATRate{{Rate: "7.7"}},
+				Conditions: []qrbill.PaymentCondition{
+					{Discount: "2", Days: 10},
+					{Discount: "0", Days: 30},
+				},
+			},
+		},
+
+		{
+			encoded: `//S1/10/X.66711\/8824/11/200712/20/MW-2020-04/30/107978798/32/2.5:117.22/40/3:5;1.5:20;1:40;0:60`,
+			s1: qrbill.SwicoS1{
+				InvoiceNumber:     "X.66711/8824",
+				InvoiceDate:       date(2020, time.July, 12),
+				CustomerReference: "MW-2020-04",
+				VATNumber:         "107978798",
+				VATDetails:        []qrbill.VATRate{{Rate: "2.5", Amount: "117.22"}},
+				Conditions: []qrbill.PaymentCondition{
+					{Discount: "3", Days: 5},
+					{Discount: "1.5", Days: 20},
+					{Discount: "1", Days: 40},
+					{Discount: "0", Days: 60},
+				},
+			},
+		},
+
+		{
+			encoded: "//S1/10/4031202511/11/201223/30/105493567/31/201201201231/32/8:49.82;2.5:14.85/33/7.7:1.09;2.5:0.37",
+			s1: qrbill.SwicoS1{
+				InvoiceNumber: "4031202511",
+				InvoiceDate:   date(2020, time.December, 23),
+				VATNumber:     "105493567",
+				VATDate:       date(2020, time.December, 1),
+				VATDateEnd:    date(2020, time.December, 31),
+				VATDetails: []qrbill.VATRate{
+					{Rate: "8", Amount: "49.82"},
+					{Rate: "2.5", Amount: "14.85"},
+				},
+				ImportTax: []qrbill.VATRate{
+					{Rate: "7.7", Amount: "1.09"},
+					{Rate: "2.5", Amount: "0.37"},
+				},
+			},
+		},
+	} {
+		t.Run(tt.encoded, func(t *testing.T) {
+			if got, want := tt.s1.String(), tt.encoded; got != want {
+				t.Errorf("String() = %q, want %q", got, want)
+			}
+			parsed, err := qrbill.ParseSwicoS1(tt.encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*parsed, tt.s1) {
+				t.Errorf("ParseSwicoS1() = %+v, want %+v", *parsed, tt.s1)
+			}
+		})
+	}
+}
+
+func TestParseSwicoS1Errors(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"//S2/10/123",
+		"//S1/10",
+		"//S1/11/10/10/123",  // out of order
+		"//S1/10/1/10/2",     // repeated
+		"//S1/11/191332",     // invalid date
+		"//S1/31/1905",       // invalid date length
+		"//S1/32/7.7;2.5:10", // rate without amount in a list
+		"//S1/40/2",          // condition without days
+		"//S1/10/123\\",      // dangling escape
+	} {
+		t.Run(encoded, func(t *testing.T) {
+			if _, err := qrbill.ParseSwicoS1(encoded); err == nil {
+				t.Errorf("ParseSwicoS1(%q) unexpectedly succeeded", encoded)
+			}
+		})
+	}
+}
+
+func TestStrdBkgInfValidation(t *testing.T) {
+	s1 := qrbill.SwicoS1{
+		InvoiceNumber: "10201409",
+		InvoiceDate:   date(2019, time.May, 12),
+		VATDetails:    []qrbill.VATRate{{Rate: "7.7"}},
+	}
+	qrch := testQRCH()
+	qrch.RmtInf.AddInf.StrdBkgInf = s1.String()
+	qrch.RmtInf.AddInf.Ustrd = strings.Repeat("x", 120)
+
+	var verr *qrbill.ValidationError
+	if err := qrch.ValidateStrict(); !errors.As(err, &verr) {
+		t.Fatalf("ValidateStrict() = %v, want *ValidationError", err)
+	}
+	if got, want := verr.Violations[0].Field, "RmtInf.AddInf"; got != want {
+		t.Errorf("violation field = %q, want %q", got, want)
+	}
+
+	// Validate shortens the unstructured message to make room:
+	validated := qrch.Validate()
+	if got, want := len(validated.RmtInf.AddInf.Ustrd)+len(validated.RmtInf.AddInf.StrdBkgInf), 140; got != want {
+		t.Errorf("combined length = %d, want %d", got, want)
+	}
+	if got, want := validated.RmtInf.AddInf.StrdBkgInf, s1.String(); got != want {
+		t.Errorf("StrdBkgInf = %q, want %q", got, want)
+	}
+	parsed, err := validated.RmtInf.AddInf.SwicoS1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*parsed, s1) {
+		t.Errorf("SwicoS1() = %+v, want %+v", *parsed, s1)
+	}
+
+	qrch.RmtInf.AddInf.Ustrd = "Spende 420"
+	qrch.RmtInf.AddInf.StrdBkgInf = "//S1/11/191332"
+	if err := qrch.ValidateStrict(); !errors.As(err, &verr) {
+		t.Fatalf("ValidateStrict() = %v, want *ValidationError", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrbill
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation describes a field which does not satisfy the rules of the Swiss
+// Implementation Guidelines QR-bill.
+type Violation struct {
+	Field string // Field path, e.g. CdtrInf.Cdtr.Name
+	Rule  string // Violated rule, e.g. “max. 70 characters”
+	Value string // Offending value
+}
+
+// String implements fmt.Stringer.
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (got %q)", v.Field, v.Rule, v.Value)
+}
+
+// ValidationError is returned by strict validation and lists all violations.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for idx, v := range e.Violations {
+		msgs[idx] = v.String()
+	}
+	return fmt.Sprintf("%d validation error(s): %s", len(e.Violations), strings.Join(msgs, "; "))
+}
+
+// violations collects Violations while checking a QRCH.
+type violations []Violation
+
+func (vs *violations) add(field, rule, value string) {
+	*vs = append(*vs, Violation{
+		Field: field,
+		Rule:  rule,
+		Value: value,
+	})
+}
+
+// maxLen adds a violation if value is longer than max characters.
+func (vs *violations) maxLen(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		vs.add(field, fmt.Sprintf("max. %d characters", max), value)
+	}
+}
+
+// fixed adds a violation if value is neither empty (will be filled in) nor
+// the fixed value want.
+func (vs *violations) fixed(field, value, want string) {
+	if value != "" && value != want {
+		vs.add(field, fmt.Sprintf("fixed value %q", want), value)
+	}
+}
+
+// err returns a *ValidationError if any violations were collected, or nil
+// otherwise.
+func (vs violations) err() error {
+	if len(vs) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: vs}
+}
+
+// countryPrefixRe matches postal codes with a country code prefix, e.g.
+// CH-8004.
+var countryPrefixRe = regexp.MustCompile(`^[A-Za-z]{1,3}-([0-9].*)$`)
+
+func (a Address) check(vs *violations, prefix string, o *options) {
+	// Addresses are either present as a whole or absent as a whole:
+	if a.empty() {
+		return
+	}
+	switch a.AdrTp {
+	case AddressTypeStructured, AddressTypeCombined:
+	default:
+		vs.add(prefix+"AdrTp", "S (structured) or K (combined)", string(a.AdrTp))
+	}
+	if o.version == SpecVersion23 && a.AdrTp == AddressTypeCombined {
+		vs.add(prefix+"AdrTp", "structured address (S) only as of version 2.3", string(a.AdrTp))
+	}
+	if a.Name == "" {
+		vs.add(prefix+"Name", "required", a.Name)
+	}
+	switch a.AdrTp {
+	case AddressTypeStructured:
+		vs.maxLen(prefix+"BldgNbOrAdrLine2", a.BldgNbOrAdrLine2, 16)
+		if a.PstCd == "" {
+			vs.add(prefix+"PstCd", "required for structured addresses", a.PstCd)
+		}
+		if a.TwnNm == "" {
+			vs.add(prefix+"TwnNm", "required for structured addresses", a.TwnNm)
+		}
+	case AddressTypeCombined:
+		vs.maxLen(prefix+"BldgNbOrAdrLine2", a.BldgNbOrAdrLine2, 70)
+		if a.BldgNbOrAdrLine2 == "" {
+			vs.add(prefix+"BldgNbOrAdrLine2", "required for combined addresses", a.BldgNbOrAdrLine2)
+		}
+		if a.PstCd != "" {
+			vs.add(prefix+"PstCd", "must be empty for combined addresses", a.PstCd)
+		}
+		if a.TwnNm != "" {
+			vs.add(prefix+"TwnNm", "must be empty for combined addresses", a.TwnNm)
+		}
+	}
+	if countryPrefixRe.MatchString(a.PstCd) {
+		vs.add(prefix+"PstCd", "without country code prefix", a.PstCd)
+	}
+	if _, ok := CountryName(a.Ctry); !ok {
+		vs.add(prefix+"Ctry", "ISO 3166-1 alpha-2 country code", a.Ctry)
+	}
+	for _, f := range []struct{ name, value string }{
+		{"Name", a.Name},
+		{"StrtNmOrAdrLine1", a.StrtNmOrAdrLine1},
+		{"BldgNbOrAdrLine2", a.BldgNbOrAdrLine2},
+		{"PstCd", a.PstCd},
+		{"TwnNm", a.TwnNm},
+	} {
+		if !o.version.permittedChars(f.value) {
+			vs.add(prefix+f.name, "permitted characters only", f.value)
+		}
+	}
+	vs.maxLen(prefix+"Name", a.Name, 70)
+	vs.maxLen(prefix+"StrtNmOrAdrLine1", a.StrtNmOrAdrLine1, 70)
+	vs.maxLen(prefix+"PstCd", a.PstCd, 16)
+	vs.maxLen(prefix+"TwnNm", a.TwnNm, 35)
+}
+
+// ValidateStrict returns a *ValidationError listing all fields which violate
+// the rules for the address type: the name and country (ISO 3166-1 alpha-2)
+// are required, structured addresses (S) require postal code and town,
+// combined addresses (K) must not contain them. An empty address is valid.
+// With WithSpecVersion(SpecVersion23), combined addresses are rejected.
+func (a Address) ValidateStrict(opts ...Option) error {
+	var vs violations
+	a.check(&vs, "", newOptions(opts))
+	return vs.err()
+}
+
+func (a QRCHCcyAmt) check(vs *violations, prefix string) {
+	if v := a.Amt; v != "" {
+		// Amounts must be formatted without leading zeros, with a decimal
+		// point and two decimal places, which is what Amount.String does:
+		if parsed, err := ParseAmount(v); err != nil || parsed.String() != v {
+			vs.add(prefix+"Amt", "decimal with two decimal places, e.g. 50.00", v)
+		} else if err := parsed.Validate(); err != nil {
+			vs.add(prefix+"Amt", err.Error(), v)
+		}
+	}
+	if a.Ccy.Validate() != nil {
+		vs.add(prefix+"Ccy", "CHF or EUR only", string(a.Ccy))
+	}
+}
+
+// ValidateStrict returns a *ValidationError if the amount is not formatted as
+// required by the Implementation Guidelines or the currency is not permitted,
+// instead of re-formatting it like Validate does.
+func (a QRCHCcyAmt) ValidateStrict() error {
+	var vs violations
+	a.check(&vs, "")
+	return vs.err()
+}
+
+func (q *QRCH) check(vs *violations, o *options) {
+	vs.fixed("Header.QRType", q.Header.QRType, QRType)
+	vs.fixed("Header.Version", q.Header.Version, Version)
+	vs.fixed("Header.Coding", q.Header.Coding, CodingType)
+
+	iban := q.CdtrInf.IBAN
+	if nonAlphanumericRe.MatchString(iban) {
+		vs.add("CdtrInf.IBAN", "alphanumeric characters only", iban)
+	} else if err := ValidateIBAN(iban); err != nil {
+		vs.add("CdtrInf.IBAN", err.Error(), iban)
+	} else {
+		// As per section 3.1, the reference type must match the IBAN type:
+		qrIBAN := IsQRIBAN(iban)
+		if qrIBAN && q.RmtInf.Tp != ReferenceTypeQRR {
+			vs.add("RmtInf.Tp", "QR-IBAN requires reference type "+ReferenceTypeQRR, q.RmtInf.Tp)
+		}
+		if !qrIBAN && q.RmtInf.Tp == ReferenceTypeQRR {
+			vs.add("RmtInf.Tp", "reference type "+ReferenceTypeQRR+" requires a QR-IBAN", q.RmtInf.Tp)
+		}
+	}
+	if q.CdtrInf.Cdtr.empty() {
+		vs.add("CdtrInf.Cdtr", "required", "")
+	}
+	q.CdtrInf.Cdtr.check(vs, "CdtrInf.Cdtr.", o)
+	q.UltmtCdtr.check(vs, "UltmtCdtr.", o)
+	q.CcyAmt.check(vs, "CcyAmt.")
+	if q.CcyAmt.Amt == notificationAmount && !q.IsNotification() {
+		vs.add("CcyAmt.Amt", "0.00 only for notifications (see QRCH.SetNotification)", q.CcyAmt.Amt)
+	}
+	q.UltmtDbtr.check(vs, "UltmtDbtr.", o)
+
+	if err := q.RmtInf.ValidateReference(); err != nil {
+		field := "RmtInf.Ref"
+		if q.RmtInf.Tp != ReferenceTypeQRR &&
+			q.RmtInf.Tp != ReferenceTypeSCOR &&
+			q.RmtInf.Tp != ReferenceTypeNON {
+			field = "RmtInf.Tp"
+		}
+		vs.add(field, err.Error(), q.RmtInf.Tp+" "+q.RmtInf.Ref)
+	}
+
+	ustrd := q.RmtInf.AddInf.Ustrd
+	if !o.version.permittedChars(ustrd) {
+		vs.add("RmtInf.AddInf.Ustrd", "permitted characters only", ustrd)
+	}
+	vs.maxLen("RmtInf.AddInf.Ustrd", ustrd, 140)
+	vs.fixed("RmtInf.AddInf.Trailer", q.RmtInf.AddInf.Trailer, Trailer)
+
+	strdBkgInf := q.RmtInf.AddInf.StrdBkgInf
+	if !o.version.permittedChars(strdBkgInf) {
+		vs.add("RmtInf.AddInf.StrdBkgInf", "permitted characters only", strdBkgInf)
+	}
+	vs.maxLen("RmtInf.AddInf.StrdBkgInf", strdBkgInf, 140)
+	if combined := utf8.RuneCountInString(ustrd) + utf8.RuneCountInString(strdBkgInf); combined > 140 {
+		vs.add("RmtInf.AddInf", "Ustrd and StrdBkgInf max. 140 characters combined", ustrd+"\n"+strdBkgInf)
+	}
+	if strings.HasPrefix(strdBkgInf, swicoS1Prefix+"/") {
+		if _, err := ParseSwicoS1(strdBkgInf); err != nil {
+			vs.add("RmtInf.AddInf.StrdBkgInf", "valid Swico S1 syntax: "+err.Error(), strdBkgInf)
+		}
+	}
+
+	if n := len(q.AltPmtInf.AltPmt); n > 2 {
+		vs.add("AltPmtInf.AltPmt", "max. 2 alternative procedures", fmt.Sprintf("%d entries", n))
+	}
+	for idx, v := range q.AltPmtInf.AltPmt {
+		field := fmt.Sprintf("AltPmtInf.AltPmt[%d]", idx)
+		if !o.version.permittedChars(v) {
+			vs.add(field, "permitted characters only", v)
+		}
+		vs.maxLen(field, v, 100)
+	}
+}
+
+// ValidateStrict checks q against the rules of the Implementation Guidelines
+// without modifying it. Unlike Validate, which truncates and strips invalid
+// input, ValidateStrict returns a *ValidationError listing every violation.
+//
+// The fixed values (Header, Trailer) may be left empty. Use WithSpecVersion to
+// validate against a newer version of the Implementation Guidelines.
+func (q *QRCH) ValidateStrict(opts ...Option) error {
+	o := newOptions(opts)
+	if o.version != SpecVersion21 && o.version != SpecVersion23 {
+		return fmt.Errorf("unsupported spec version %q", o.version)
+	}
+	var vs violations
+	q.check(&vs, o)
+	return vs.err()
+}
+
+// Option configures validation and encoding of a QRCH.
+type Option func(*options)
+
+type options struct {
+	strict  bool
+	version SpecVersion
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		version: SpecVersion21,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithStrictValidation makes Encode validate the QRCH using ValidateStrict
+// and return its *ValidationError, instead of truncating and stripping
+// invalid input.
+func WithStrictValidation() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}